@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"flowChart/domain"
@@ -11,13 +12,18 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// BaseFlowChartAggregate stores flowcharts in a SQL database. Queries are
+// written with ? placeholders and rebound for the driver; what else differs
+// between databases is left to the dialect.
 type BaseFlowChartAggregate[T any] struct {
-	client *sqlx.DB
+	client  *sqlx.DB
+	dialect sqlDialect
 }
 
 func NewBaseFlowchartAggregate[T any](db *sqlx.DB) *BaseFlowChartAggregate[T] {
 	return &BaseFlowChartAggregate[T]{
-		client: db,
+		client:  db,
+		dialect: postgresDialect{},
 	}
 }
 
@@ -27,47 +33,75 @@ func ToJsonB(value any) []byte {
 }
 
 func (r *BaseFlowChartAggregate[T]) StoreFlowChart(ctx context.Context, flowChart *domain.FlowChart[T]) error {
-	query := `INSERT into flowchart (title, key) VALUES ($1, $2) RETURNING id`
-	stmt, err := r.client.PrepareContext(ctx, query)
+	return r.RunInTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := tx.Rebind(`INSERT into flowchart (title, key, viewport) VALUES (?, ?, ?) RETURNING id`)
+		stmt, err := tx.PrepareContext(ctx, query)
 
-	if err != nil {
-		return fmt.Errorf("error to prepare flowchart stmt: %w", err)
-	}
+		if err != nil {
+			return fmt.Errorf("error to prepare flowchart stmt: %w", err)
+		}
 
-	err = stmt.QueryRowContext(ctx, flowChart.Title, flowChart.Key).Scan(&flowChart.Id)
+		defer stmt.Close()
 
-	if err != nil {
-		return fmt.Errorf("error storing an user: %w", err)
-	}
+		err = stmt.QueryRowContext(ctx, flowChart.Title, flowChart.Key, viewportJson(flowChart.Viewport)).Scan(&flowChart.Id)
 
-	return r.editNode(ctx, flowChart)
+		if r.dialect.uniqueViolation(err) {
+			return fmt.Errorf("error storing a flowchart: key %q: %w", flowChart.Key, domain.ErrFlowChartExists)
+		}
 
+		if err != nil {
+			return fmt.Errorf("error storing a flowchart: %w", err)
+		}
+
+		return r.editNode(ctx, tx, flowChart)
+	})
 }
 
 func (r *BaseFlowChartAggregate[T]) UpdateFlowChart(ctx context.Context, flowChart *domain.FlowChart[T]) error {
-	query := `UPDATE flowchart set title=$1 WHERE key=$2`
+	return r.RunInTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		if err := r.lockVersion(ctx, tx, flowChart); err != nil {
+			return err
+		}
 
-	stmt, err := r.client.PrepareContext(ctx, query)
+		query := tx.Rebind(`UPDATE flowchart set title=?, viewport=? WHERE id=?`)
 
-	if err != nil {
-		return fmt.Errorf("error preparing stmt to update a flowchart: %w", err)
-	}
+		stmt, err := tx.PrepareContext(ctx, query)
 
-	result, err := stmt.ExecContext(ctx, flowChart.Title, flowChart.Key)
+		if err != nil {
+			return fmt.Errorf("error preparing stmt to update a flowchart: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("error updating a flowchart: %w", err)
+		defer stmt.Close()
+
+		if _, err := stmt.ExecContext(ctx, flowChart.Title, viewportJson(flowChart.Viewport), flowChart.Id); err != nil {
+			return fmt.Errorf("error updating a flowchart: %w", err)
+		}
+
+		return r.editNode(ctx, tx, flowChart)
+	})
+}
+
+// lockVersion locks the flowchart row until the transaction ends and makes sure
+// nobody saved it since the version the change was based on.
+func (r *BaseFlowChartAggregate[T]) lockVersion(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	query := tx.Rebind("SELECT id, version FROM flowchart WHERE key=?" + r.dialect.forUpdate())
+
+	var current int
+	err := tx.QueryRowContext(ctx, query, flowChart.Key).Scan(&flowChart.Id, &current)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrFlowChartNotFound
 	}
 
-	if rows, err := result.RowsAffected(); rows == 0 {
-		return fmt.Errorf("there is no flowchart to the given key: %w", err)
+	if err != nil {
+		return fmt.Errorf("error locking a flowchart: %w", err)
 	}
 
-	return r.editNode(ctx, flowChart)
+	return flowChart.CheckVersion(current, true)
 }
 
 func (r *BaseFlowChartAggregate[T]) FlowChartExists(ctx context.Context, flowChart *domain.FlowChart[T]) (bool, error) {
-	query := "SELECT id FROM flowchart WHERE key=$1"
+	query := r.client.Rebind("SELECT id FROM flowchart WHERE key=?")
 
 	err := r.client.QueryRowContext(ctx, query, flowChart.Key).Scan(&flowChart.Id)
 
@@ -75,7 +109,7 @@ func (r *BaseFlowChartAggregate[T]) FlowChartExists(ctx context.Context, flowCha
 		return flowChart.Id != "", nil
 	}
 
-	if err.Error() == "sql: no rows in result set" {
+	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
 
@@ -83,92 +117,30 @@ func (r *BaseFlowChartAggregate[T]) FlowChartExists(ctx context.Context, flowCha
 
 }
 
-func (r *BaseFlowChartAggregate[T]) deleteNodes(ctx context.Context, tx *sqlx.Tx, flowchartID string) error {
-	query := "DELETE FROM node WHERE flowchart_id=$1"
-
-	stmt, err := tx.PrepareContext(ctx, query)
-
-	if err != nil {
-		return fmt.Errorf("error preparing stmt to delete a node: %w", err)
+func (r *BaseFlowChartAggregate[T]) editNode(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	if err := r.syncNodes(ctx, tx, flowChart); err != nil {
+		return err
 	}
 
-	if _, err := stmt.ExecContext(ctx, flowchartID); err != nil {
-		return fmt.Errorf("error deleting a node: %w", err)
+	if err := r.syncEdges(ctx, tx, flowChart); err != nil {
+		return err
 	}
 
-	return nil
+	return r.createVersion(ctx, tx, flowChart)
 }
 
-func (r *BaseFlowChartAggregate[T]) createNode(ctx context.Context, tx *sqlx.Tx, flowchartID string, node *domain.Node[T]) error {
-	query := `INSERT into node (internal_id, parent_id, flowchart_id, dragging, selected, position_absolute, height, width, position, data, type)
-	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
-
-	stmt, err := tx.PrepareContext(ctx, query)
-
-	if err != nil {
-		return fmt.Errorf("error preparing stmt to Store a node: %w", err)
-	}
-
-	defer stmt.Close()
-
-	if _, err := stmt.ExecContext(
-		ctx,
-		node.NodeID,
-		node.ParentId(),
-		flowchartID,
-		node.Dragging,
-		node.Selected,
-		node.PositionAbsolute,
-		node.Height,
-		node.Width,
-		node.Position,
-		ToJsonB(node.Data),
-		node.Type,
-	); err != nil {
-		return fmt.Errorf("error creating a node: %w", err)
-	}
-
-	return nil
+func (r *BaseFlowChartAggregate[T]) RunInTransaction(ctx context.Context, txFunc func(ctx context.Context, tx *sqlx.Tx) error) error {
+	return r.runInTransaction(ctx, nil, txFunc)
 }
 
-func (r *BaseFlowChartAggregate[T]) editNode(ctx context.Context, flowChart *domain.FlowChart[T]) error {
-
-	saveFunc := func(tx *sqlx.Tx, n *domain.Node[T]) error {
-		return r.createNode(ctx, tx, flowChart.Id, n)
-	}
-
-	var errR error
-	handlerErr := func(err error) {
-		if err == nil {
-			return
-		}
-
-		if errR != nil {
-			errR = errors.Join(errR, err)
-			return
-		}
-
-		errR = err
-	}
-
-	return r.RunInTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
-		if err := r.deleteNodes(ctx, tx, flowChart.Id); err != nil {
-			return err
-		}
-
-		flowChart.Node.Traverse(domain.TraversePreOrder, domain.TraverseAll, -1, func(n *domain.Node[T]) bool {
-			err := saveFunc(tx, n)
-			handlerErr(err)
-			return false
-		})
-
-		return errR
-
-	})
+// RunInReadTransaction runs reads that have to see the database as it was at a
+// single point in time, such as a flowchart row and the rows of its nodes.
+func (r *BaseFlowChartAggregate[T]) RunInReadTransaction(ctx context.Context, txFunc func(ctx context.Context, tx *sqlx.Tx) error) error {
+	return r.runInTransaction(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, txFunc)
 }
 
-func (r *BaseFlowChartAggregate[T]) RunInTransaction(ctx context.Context, txFunc func(ctx context.Context, tx *sqlx.Tx) error) error {
-	tx, err := r.client.Beginx()
+func (r *BaseFlowChartAggregate[T]) runInTransaction(ctx context.Context, opts *sql.TxOptions, txFunc func(ctx context.Context, tx *sqlx.Tx) error) (err error) {
+	tx, err := r.client.BeginTxx(ctx, opts)
 
 	if err != nil {
 		return fmt.Errorf("error beginning a transaction: %w", err)
@@ -182,91 +154,227 @@ func (r *BaseFlowChartAggregate[T]) RunInTransaction(ctx context.Context, txFunc
 		err = tx.Commit()
 	}()
 
-	err = txFunc(ctx, tx)
+	return txFunc(ctx, tx)
+}
+
+// GetFlowChart reads the flowchart with its nodes and edges in one read-only
+// transaction, so a save landing in between cannot mix two versions.
+func (r *BaseFlowChartAggregate[T]) GetFlowChart(ctx context.Context, key string) (*FlowChartModel[T], error) {
+	flow := &FlowChartModel[T]{}
 
-	if err != nil {
-		return err
-	}
+	err := r.RunInReadTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		return r.getFlowChart(ctx, tx, key, flow)
+	})
 
-	return nil
+	return flow, err
 }
 
-func (r *BaseFlowChartAggregate[T]) GetFlowChart(ctx context.Context, key string) (*FlowChartModel[T], error) {
-	query := `
+func (r *BaseFlowChartAggregate[T]) getFlowChart(ctx context.Context, tx *sqlx.Tx, key string, flow *FlowChartModel[T]) error {
+	query := tx.Rebind("SELECT id, title, key, version, viewport FROM flowchart WHERE key=?")
+
+	err := tx.QueryRowContext(ctx, query, key).Scan(&flow.ID, &flow.Title, &flow.Key, &flow.Version, &flow.Viewport)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrFlowChartNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("error querying a flowchart %w", err)
+	}
+
+	query = fmt.Sprintf(`
 	SELECT
-		flow.id,
-		flow.title,
-		flow.key,
 		node.internal_id,
-		node.parent_id,
-		node.position,
-  		node.data,
+		COALESCE(node.parent_id, ''),
+		%s,
+		%s,
 		node.width,
 		node.height,
-		node.position_absolute,
+		COALESCE(%s, 'null'),
 		node.selected,
 		node.dragging,
-		node.type
+		node.type,
+		COALESCE(node.parent_node, ''),
+		node.extent,
+		node.z_index,
+		node.hidden,
+		node.style,
+		node.extra
 	FROM
 		node
-	JOIN
-		(
-		SELECT *
-		FROM flowchart
-		WHERE flowchart.key = $1
-	) as flow
-	ON
-	flow.id = node.flowchart_id
-	`
+	WHERE
+		node.flowchart_id = ?
+	ORDER BY
+		node.sort_order
+	`, r.dialect.text("node.position"), r.dialect.text("node.data"), r.dialect.text("node.position_absolute"))
 
-	flow := &FlowChartModel[T]{}
-
-	rows, err := r.client.QueryxContext(ctx, query, key)
+	rows, err := tx.QueryxContext(ctx, tx.Rebind(query), flow.ID)
 
 	if err != nil {
-		return flow, fmt.Errorf("error querying a flowchart: %w", err)
+		return fmt.Errorf("error querying a flowchart: %w", err)
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
 		node := &NodeModel[T]{}
-		var (
-			flowchartID    string
-			flowchartTitle string
-			flowchartKey   string
-		)
-		if err := rows.Err(); err != nil {
-			return flow, fmt.Errorf("error querying a flowchart %w", err)
-		}
+		var position, data, positionAbsolute []byte
 
 		err := rows.Scan(
-			&flowchartID,
-			&flowchartTitle,
-			&flowchartKey,
 			&node.NodeID,
 			&node.ParentID,
-			&node.Position,
-			&node.Data,
+			&position,
+			&data,
 			&node.Width,
 			&node.Height,
-			&node.PositionAbsolute,
+			&positionAbsolute,
 			&node.Selected,
 			&node.Dragging,
 			&node.Type,
+			&node.ParentNode,
+			&node.Extent,
+			&node.ZIndex,
+			&node.Hidden,
+			&node.Style,
+			&node.Extra,
 		)
 		if err != nil {
-			return flow, fmt.Errorf("error querying a flowchart %w", err)
+			return fmt.Errorf("error querying a flowchart %w", err)
+		}
+
+		if err := json.Unmarshal(position, &node.Position); err != nil {
+			return fmt.Errorf("error decoding node %s position: %w", node.NodeID, err)
+		}
+
+		if err := json.Unmarshal(positionAbsolute, &node.PositionAbsolute); err != nil {
+			return fmt.Errorf("error decoding node %s position: %w", node.NodeID, err)
 		}
 
-		if flow.ID == "" {
-			flow.ID = flowchartID
-			flow.Title = flowchartTitle
-			flow.Key = flowchartKey
+		if err := json.Unmarshal(data, &node.Data); err != nil {
+			return fmt.Errorf("error decoding node %s data: %w", node.NodeID, err)
 		}
 
+		node.ParentId = node.ParentNode
 		flow.AddNode(node)
 
 	}
 
-	return flow, nil
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error querying a flowchart %w", err)
+	}
+
+	if err := r.getEdges(ctx, tx, flow); err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// LoadFlowChart reads a stored flowchart back into its domain tree.
+func (r *BaseFlowChartAggregate[T]) LoadFlowChart(ctx context.Context, key string) (*domain.FlowChart[T], error) {
+	flow, err := r.GetFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return flow.ToDomain()
+}
+
+func (r *BaseFlowChartAggregate[T]) DeleteFlowChart(ctx context.Context, key string) error {
+	query := r.client.Rebind("DELETE FROM flowchart WHERE key=?")
+
+	result, err := r.client.ExecContext(ctx, query, key)
+
+	if err != nil {
+		return fmt.Errorf("error deleting a flowchart: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("error deleting a flowchart: %w", err)
+	}
+
+	if rows == 0 {
+		return domain.ErrFlowChartNotFound
+	}
+
+	return nil
+}
+
+func (r *BaseFlowChartAggregate[T]) ListFlowCharts(ctx context.Context, limit int, offset int) (*FlowChartPageModel, error) {
+	page := &FlowChartPageModel{Items: []*FlowChartSummaryModel{}, Limit: limit, Offset: offset}
+
+	if err := r.client.GetContext(ctx, &page.Total, "SELECT count(*) FROM flowchart"); err != nil {
+		return page, fmt.Errorf("error counting flowcharts: %w", err)
+	}
+
+	query := `
+	SELECT
+		id,
+		title,
+		key,
+		version,
+		created_at,
+		updated_at
+	FROM
+		flowchart
+	ORDER BY
+		key
+	LIMIT ? OFFSET ?
+	`
+
+	if err := r.client.SelectContext(ctx, &page.Items, r.client.Rebind(query), limit, offset); err != nil {
+		return page, fmt.Errorf("error listing flowcharts: %w", err)
+	}
+
+	return page, nil
+}
+
+func (r *BaseFlowChartAggregate[T]) getEdges(ctx context.Context, tx *sqlx.Tx, flow *FlowChartModel[T]) error {
+	query := `
+	SELECT
+		internal_id,
+		source,
+		target,
+		source_handle,
+		target_handle,
+		label,
+		type,
+		animated,
+		style,
+		data,
+		hidden,
+		z_index,
+		marker_start,
+		marker_end,
+		extra
+	FROM
+		edge
+	WHERE
+		flowchart_id = ?
+	ORDER BY
+		sort_order
+	`
+
+	if flow.ID == "" {
+		return nil
+	}
+
+	edges := []*EdgeModel{}
+
+	if err := tx.SelectContext(ctx, &edges, tx.Rebind(query), flow.ID); err != nil {
+		return fmt.Errorf("error querying flowchart edges: %w", err)
+	}
 
+	if len(edges) == 0 {
+		flow.deriveEdges()
+		return nil
+	}
+
+	flow.Edges = edges
+
+	return nil
 }
@@ -0,0 +1,124 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// sqlDialect holds what the flowchart aggregate writes differently for each
+// database. Placeholders are left to sqlx, which rebinds ? for the driver.
+type sqlDialect interface {
+	// text reads a JSON column as text.
+	text(column string) string
+	// forUpdate is appended to the query locking the flowchart row.
+	forUpdate() string
+	// syncRows applies a diff to one of the tables holding the rows of a
+	// flowchart. casts gives the type of each column.
+	syncRows(ctx context.Context, tx *sqlx.Tx, table string, flowchartID string, columns []string, casts []string, inserts [][]any, updates [][]any, deletes []string) error
+	// uniqueViolation reports whether err was raised by a unique constraint.
+	uniqueViolation(err error) bool
+}
+
+// maxBatchParams keeps multi-row statements under the 65535 parameters
+// Postgres accepts in a single statement.
+const maxBatchParams = 60000
+
+type postgresDialect struct{}
+
+func (postgresDialect) text(column string) string {
+	return column + "::text"
+}
+
+func (postgresDialect) forUpdate() string {
+	return " FOR UPDATE"
+}
+
+func (postgresDialect) uniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// syncRows writes each kind of change with a single statement per batch.
+func (postgresDialect) syncRows(ctx context.Context, tx *sqlx.Tx, table string, flowchartID string, columns []string, casts []string, inserts [][]any, updates [][]any, deletes []string) error {
+	if len(deletes) > 0 {
+		query := fmt.Sprintf("DELETE FROM %s WHERE flowchart_id=$1 AND internal_id = ANY($2::text[])", table)
+
+		if _, err := tx.ExecContext(ctx, query, flowchartID, pq.Array(deletes)); err != nil {
+			return fmt.Errorf("error deleting from %s: %w", table, err)
+		}
+	}
+
+	insertCasts := append([]string{""}, make([]string, len(columns))...)
+	for i := range inserts {
+		inserts[i] = append([]any{flowchartID}, inserts[i]...)
+	}
+
+	err := execBatch(ctx, tx, inserts, insertCasts, func(values string, next int) (string, []any) {
+		return fmt.Sprintf("INSERT into %s (flowchart_id, %s) VALUES %s", table, strings.Join(columns, ", "), values), nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("error inserting into %s: %w", table, err)
+	}
+
+	assignments := make([]string, 0, len(columns))
+	for _, column := range columns[1:] {
+		assignments = append(assignments, fmt.Sprintf("%s = v.%s", column, column))
+	}
+	assignments = append(assignments, "updated_at = CURRENT_TIMESTAMP")
+
+	err = execBatch(ctx, tx, updates, casts, func(values string, next int) (string, []any) {
+		query := fmt.Sprintf("UPDATE %s SET %s FROM (VALUES %s) AS v(%s) WHERE %s.flowchart_id = $%d AND %s.internal_id = v.internal_id",
+			table, strings.Join(assignments, ", "), values, strings.Join(columns, ", "), table, next, table)
+		return query, []any{flowchartID}
+	})
+
+	if err != nil {
+		return fmt.Errorf("error updating %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// execBatch runs a statement over several rows at once. build receives the
+// VALUES list and the number of the next free parameter, and returns the query
+// together with the arguments that follow the rows.
+func execBatch(ctx context.Context, tx *sqlx.Tx, rows [][]any, casts []string, build func(values string, next int) (string, []any)) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	size := maxBatchParams / len(casts)
+
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]any, 0, (end-start)*len(casts))
+
+		for _, row := range rows[start:end] {
+			placeholders := make([]string, len(row))
+			for i, value := range row {
+				args = append(args, value)
+				placeholders[i] = fmt.Sprintf("$%d%s", len(args), casts[i])
+			}
+			values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		}
+
+		query, extra := build(strings.Join(values, ", "), len(args)+1)
+
+		if _, err := tx.ExecContext(ctx, query, append(args, extra...)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
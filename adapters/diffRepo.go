@@ -0,0 +1,401 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flowChart/domain"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// canonicalJson gives two equivalent JSON documents the same text, the way
+// JSONB does when it stores them.
+func canonicalJson(raw []byte) string {
+	var value any
+
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+
+	result, _ := json.Marshal(value)
+	return string(result)
+}
+
+func toNullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func toNullJson(value json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(value), Valid: len(value) > 0}
+}
+
+// toNullJsonB is toNullJson for JSONB columns, which do not keep the text as it
+// was sent.
+func toNullJsonB(value json.RawMessage) sql.NullString {
+	return sql.NullString{String: canonicalJson(value), Valid: len(value) > 0}
+}
+
+// toNullExtra stores the fields without a column of their own as one JSON
+// object, or NULL when there are none.
+func toNullExtra(extra domain.Extra) sql.NullString {
+	if len(extra) == 0 {
+		return sql.NullString{}
+	}
+	return toNullJsonB(ToJsonB(extra))
+}
+
+func toNullInt(value *int) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*value), Valid: true}
+}
+
+type nodeRow struct {
+	InternalID       string
+	ParentID         sql.NullString
+	Dragging         bool
+	Selected         bool
+	PositionAbsolute string
+	Height           float64
+	Width            float64
+	Position         string
+	Data             string
+	Type             string
+	SortOrder        int
+	ParentNode       sql.NullString
+	Extent           sql.NullString
+	ZIndex           sql.NullInt64
+	Hidden           bool
+	Style            sql.NullString
+	Extra            sql.NullString
+}
+
+func newNodeRow[T any](node *domain.Node[T], sortOrder int) nodeRow {
+	return nodeRow{
+		InternalID:       node.NodeID,
+		ParentID:         toNullString(node.ParentId()),
+		Dragging:         node.Dragging,
+		Selected:         node.Selected,
+		PositionAbsolute: canonicalJson(ToJsonB(node.PositionAbsolute)),
+		Height:           node.Height,
+		Width:            node.Width,
+		Position:         canonicalJson(ToJsonB(node.Position)),
+		Data:             canonicalJson(ToJsonB(node.Data)),
+		Type:             node.Type,
+		SortOrder:        sortOrder,
+		ParentNode:       toNullString(node.ParentNode),
+		Extent:           toNullJsonB(node.Extent),
+		ZIndex:           toNullInt(node.ZIndex),
+		Hidden:           node.Hidden,
+		Style:            toNullJsonB(node.Style),
+		Extra:            toNullExtra(node.Extra),
+	}
+}
+
+func (n nodeRow) values() []any {
+	return []any{n.InternalID, n.ParentID, n.Dragging, n.Selected, n.PositionAbsolute, n.Height, n.Width, n.Position, n.Data, n.Type, n.SortOrder,
+		n.ParentNode, n.Extent, n.ZIndex, n.Hidden, n.Style, n.Extra}
+}
+
+var nodeColumns = []string{"internal_id", "parent_id", "dragging", "selected", "position_absolute", "height", "width", "position", "data", "type", "sort_order",
+	"parent_node", "extent", "z_index", "hidden", "style", "extra"}
+var nodeCasts = []string{"::varchar", "::varchar", "::boolean", "::boolean", "::jsonb", "::float8", "::float8", "::jsonb", "::jsonb", "::varchar", "::int",
+	"::varchar", "::jsonb", "::int", "::boolean", "::jsonb", "::jsonb"}
+
+type edgeRow struct {
+	InternalID   string
+	Source       string
+	Target       string
+	SourceHandle sql.NullString
+	TargetHandle sql.NullString
+	Label        string
+	Type         string
+	Animated     sql.NullBool
+	Style        sql.NullString
+	Data         sql.NullString
+	SortOrder    int
+	Hidden       bool
+	ZIndex       sql.NullInt64
+	MarkerStart  sql.NullString
+	MarkerEnd    sql.NullString
+	Extra        sql.NullString
+}
+
+func newEdgeRow(edge *domain.Edge, sortOrder int) edgeRow {
+	row := edgeRow{
+		InternalID:  edge.ID,
+		Source:      edge.Source,
+		Target:      edge.Target,
+		Label:       edge.Label,
+		Type:        edge.Type,
+		Style:       toNullJson(edge.Style),
+		Data:        toNullJson(edge.Data),
+		SortOrder:   sortOrder,
+		Hidden:      edge.Hidden,
+		ZIndex:      toNullInt(edge.ZIndex),
+		MarkerStart: toNullJson(edge.MarkerStart),
+		MarkerEnd:   toNullJson(edge.MarkerEnd),
+		Extra:       toNullExtra(edge.Extra),
+	}
+
+	if edge.SourceHandle != nil {
+		row.SourceHandle = sql.NullString{String: *edge.SourceHandle, Valid: true}
+	}
+
+	if edge.TargetHandle != nil {
+		row.TargetHandle = sql.NullString{String: *edge.TargetHandle, Valid: true}
+	}
+
+	if edge.Animated != nil {
+		row.Animated = sql.NullBool{Bool: *edge.Animated, Valid: true}
+	}
+
+	return row
+}
+
+func (e edgeRow) values() []any {
+	return []any{e.InternalID, e.Source, e.Target, e.SourceHandle, e.TargetHandle, e.Label, e.Type, e.Animated, e.Style, e.Data, e.SortOrder,
+		e.Hidden, e.ZIndex, e.MarkerStart, e.MarkerEnd, e.Extra}
+}
+
+var edgeColumns = []string{"internal_id", "source", "target", "source_handle", "target_handle", "label", "type", "animated", "style", "data", "sort_order",
+	"hidden", "z_index", "marker_start", "marker_end", "extra"}
+var edgeCasts = []string{"::varchar", "::varchar", "::varchar", "::varchar", "::varchar", "::varchar", "::varchar", "::boolean", "::json", "::json", "::int",
+	"::boolean", "::int", "::json", "::json", "::jsonb"}
+
+// rowDiff splits the wanted rows into the ones to insert and to update, and
+// returns the ids of the stored rows that are no longer wanted.
+func rowDiff[R comparable](stored map[string]R, wanted []R, id func(R) string) (inserts []R, updates []R, deletes []string) {
+	seen := make(map[string]bool, len(wanted))
+
+	for _, row := range wanted {
+		seen[id(row)] = true
+
+		current, ok := stored[id(row)]
+		switch {
+		case !ok:
+			inserts = append(inserts, row)
+		case current != row:
+			updates = append(updates, row)
+		}
+	}
+
+	for storedID := range stored {
+		if !seen[storedID] {
+			deletes = append(deletes, storedID)
+		}
+	}
+
+	return inserts, updates, deletes
+}
+
+func (r *BaseFlowChartAggregate[T]) storedNodes(ctx context.Context, tx *sqlx.Tx, flowchartID string) (map[string]nodeRow, error) {
+	d := r.dialect
+	query := fmt.Sprintf(`
+	SELECT
+		internal_id,
+		parent_id,
+		dragging,
+		selected,
+		COALESCE(%s, 'null'),
+		height,
+		width,
+		%s,
+		%s,
+		type,
+		sort_order,
+		parent_node,
+		%s,
+		z_index,
+		hidden,
+		%s,
+		%s
+	FROM
+		node
+	WHERE
+		flowchart_id = ?
+	`, d.text("position_absolute"), d.text("position"), d.text("data"), d.text("extent"), d.text("style"), d.text("extra"))
+
+	rows, err := tx.QueryContext(ctx, tx.Rebind(query), flowchartID)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying stored nodes: %w", err)
+	}
+
+	defer rows.Close()
+
+	stored := map[string]nodeRow{}
+
+	for rows.Next() {
+		row := nodeRow{}
+
+		if err := rows.Scan(&row.InternalID, &row.ParentID, &row.Dragging, &row.Selected, &row.PositionAbsolute,
+			&row.Height, &row.Width, &row.Position, &row.Data, &row.Type, &row.SortOrder,
+			&row.ParentNode, &row.Extent, &row.ZIndex, &row.Hidden, &row.Style, &row.Extra); err != nil {
+			return nil, fmt.Errorf("error querying stored nodes: %w", err)
+		}
+
+		row.PositionAbsolute = canonicalJson([]byte(row.PositionAbsolute))
+		row.Position = canonicalJson([]byte(row.Position))
+		row.Data = canonicalJson([]byte(row.Data))
+		row.Extent.String = canonicalJson([]byte(row.Extent.String))
+		row.Style.String = canonicalJson([]byte(row.Style.String))
+		row.Extra.String = canonicalJson([]byte(row.Extra.String))
+		stored[row.InternalID] = row
+	}
+
+	return stored, rows.Err()
+}
+
+func (r *BaseFlowChartAggregate[T]) storedEdges(ctx context.Context, tx *sqlx.Tx, flowchartID string) (map[string]edgeRow, error) {
+	d := r.dialect
+	query := fmt.Sprintf(`
+	SELECT
+		internal_id,
+		source,
+		target,
+		source_handle,
+		target_handle,
+		label,
+		type,
+		animated,
+		%s,
+		%s,
+		sort_order,
+		hidden,
+		z_index,
+		%s,
+		%s,
+		%s
+	FROM
+		edge
+	WHERE
+		flowchart_id = ?
+	`, d.text("style"), d.text("data"), d.text("marker_start"), d.text("marker_end"), d.text("extra"))
+
+	rows, err := tx.QueryContext(ctx, tx.Rebind(query), flowchartID)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying stored edges: %w", err)
+	}
+
+	defer rows.Close()
+
+	stored := map[string]edgeRow{}
+
+	for rows.Next() {
+		row := edgeRow{}
+
+		if err := rows.Scan(&row.InternalID, &row.Source, &row.Target, &row.SourceHandle, &row.TargetHandle,
+			&row.Label, &row.Type, &row.Animated, &row.Style, &row.Data, &row.SortOrder,
+			&row.Hidden, &row.ZIndex, &row.MarkerStart, &row.MarkerEnd, &row.Extra); err != nil {
+			return nil, fmt.Errorf("error querying stored edges: %w", err)
+		}
+
+		row.Extra.String = canonicalJson([]byte(row.Extra.String))
+
+		stored[row.InternalID] = row
+	}
+
+	return stored, rows.Err()
+}
+
+// UpdateNode writes a single node whose place in the tree did not change, and
+// moves the flowchart to its next version. Without newVersion the flowchart
+// stays at its current version, which suits changes to the layout only.
+func (r *BaseFlowChartAggregate[T]) UpdateNode(ctx context.Context, flowChart *domain.FlowChart[T], node *domain.Node[T], newVersion bool) error {
+	return r.RunInTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		if err := r.lockVersion(ctx, tx, flowChart); err != nil {
+			return err
+		}
+
+		row := newNodeRow(node, 0)
+		columns := []string{"dragging", "selected", "position_absolute", "height", "width", "position", "data", "extent", "z_index", "hidden", "style", "extra"}
+
+		assignments := make([]string, 0, len(columns)+1)
+		for _, column := range columns {
+			assignments = append(assignments, column+" = ?")
+		}
+		assignments = append(assignments, "updated_at = CURRENT_TIMESTAMP")
+
+		query := fmt.Sprintf("UPDATE node SET %s WHERE flowchart_id = ? AND internal_id = ?", strings.Join(assignments, ", "))
+
+		result, err := tx.ExecContext(ctx, tx.Rebind(query), row.Dragging, row.Selected, row.PositionAbsolute, row.Height, row.Width,
+			row.Position, row.Data, row.Extent, row.ZIndex, row.Hidden, row.Style, row.Extra, flowChart.Id, row.InternalID)
+
+		if err != nil {
+			return fmt.Errorf("error updating a node: %w", err)
+		}
+
+		if rows, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("error updating a node: %w", err)
+		} else if rows == 0 {
+			return domain.ErrNodeNotFound
+		}
+
+		if !newVersion {
+			return r.touchHead(ctx, tx, flowChart)
+		}
+
+		return r.createVersion(ctx, tx, flowChart)
+	})
+}
+
+// syncNodes writes only the nodes that were added, changed or removed since the
+// flowchart was last saved.
+func (r *BaseFlowChartAggregate[T]) syncNodes(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	stored, err := r.storedNodes(ctx, tx, flowChart.Id)
+
+	if err != nil {
+		return err
+	}
+
+	order := flowChart.DrawOrder()
+	wanted := make([]nodeRow, 0, len(order))
+	for i, node := range order {
+		wanted = append(wanted, newNodeRow(node, i))
+	}
+
+	inserts, updates, deletes := rowDiff(stored, wanted, func(row nodeRow) string { return row.InternalID })
+
+	return r.dialect.syncRows(ctx, tx, "node", flowChart.Id, nodeColumns, nodeCasts, nodeValues(inserts), nodeValues(updates), deletes)
+}
+
+// syncEdges writes only the edges that were added, changed or removed since the
+// flowchart was last saved.
+func (r *BaseFlowChartAggregate[T]) syncEdges(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	stored, err := r.storedEdges(ctx, tx, flowChart.Id)
+
+	if err != nil {
+		return err
+	}
+
+	wanted := make([]edgeRow, 0, len(flowChart.Edges))
+	for i, edge := range flowChart.Edges {
+		wanted = append(wanted, newEdgeRow(edge, i))
+	}
+
+	inserts, updates, deletes := rowDiff(stored, wanted, func(row edgeRow) string { return row.InternalID })
+
+	return r.dialect.syncRows(ctx, tx, "edge", flowChart.Id, edgeColumns, edgeCasts, edgeValues(inserts), edgeValues(updates), deletes)
+}
+
+func nodeValues(rows []nodeRow) [][]any {
+	values := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row.values())
+	}
+	return values
+}
+
+func edgeValues(rows []edgeRow) [][]any {
+	values := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row.values())
+	}
+	return values
+}
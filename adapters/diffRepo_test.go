@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"encoding/json"
+	"flowChart/domain"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestRowDiff(t *testing.T) {
+	type row struct {
+		id    string
+		label string
+	}
+
+	stored := map[string]row{
+		"a": {"a", "kept"},
+		"b": {"b", "old"},
+		"c": {"c", "gone"},
+	}
+	wanted := []row{{"a", "kept"}, {"b", "new"}, {"d", "added"}}
+
+	inserts, updates, deletes := rowDiff(stored, wanted, func(r row) string { return r.id })
+	sort.Strings(deletes)
+
+	if got := fmt.Sprint(inserts, updates, deletes); got != "[{d added}] [{b new}] [c]" {
+		t.Errorf("rowDiff() = %s, want d inserted, b updated and c deleted", got)
+	}
+
+	inserts, updates, deletes = rowDiff(stored, nil, func(r row) string { return r.id })
+	sort.Strings(deletes)
+
+	if len(inserts) != 0 || len(updates) != 0 || fmt.Sprint(deletes) != "[a b c]" {
+		t.Errorf("rowDiff() with no wanted rows = %v %v %v, want every row deleted", inserts, updates, deletes)
+	}
+}
+
+func TestNodeRowExtra(t *testing.T) {
+	flowChart := newFlowChart("order")
+	flowChart.Node.Extra = domain.Extra{"measured": json.RawMessage(`{ "width": 150 }`)}
+
+	same := newFlowChart("order")
+	same.Node.Extra = domain.Extra{"measured": json.RawMessage(`{"width":150}`)}
+
+	if newNodeRow(flowChart.Node, 0) != newNodeRow(same.Node, 0) {
+		t.Errorf("rows differ on the spacing of their extra JSON, want them equal")
+	}
+
+	same.Node.Extra = nil
+	if newNodeRow(flowChart.Node, 0) == newNodeRow(same.Node, 0) {
+		t.Errorf("rows with and without extra fields are equal, want an update")
+	}
+}
@@ -0,0 +1,145 @@
+package adapters
+
+import (
+	"flowChart/domain"
+)
+
+func ToNodeModel[T any](node *domain.Node[T]) *NodeModel[T] {
+	return &NodeModel[T]{
+		NodeID:           node.NodeID,
+		ParentID:         node.ParentId(),
+		Position:         PositionModel{X: node.Position.X, Y: node.Position.Y},
+		Data:             node.Data,
+		Width:            node.Width,
+		Height:           node.Height,
+		Selected:         node.Selected,
+		PositionAbsolute: PositionModel{X: node.PositionAbsolute.X, Y: node.PositionAbsolute.Y},
+		Dragging:         node.Dragging,
+		Type:             node.Type,
+		ParentNode:       node.ParentNode,
+		ParentId:         node.ParentNode,
+		Extent:           RawJSONModel(node.Extent),
+		ZIndex:           node.ZIndex,
+		Hidden:           node.Hidden,
+		Style:            RawJSONModel(node.Style),
+		Extra:            ExtraModel(node.Extra),
+	}
+}
+
+func ToEdgeModel(edge *domain.Edge) *EdgeModel {
+	return &EdgeModel{
+		Id:           edge.ID,
+		Source:       edge.Source,
+		Target:       edge.Target,
+		SourceHandle: edge.SourceHandle,
+		TargetHandle: edge.TargetHandle,
+		Label:        edge.Label,
+		Type:         edge.Type,
+		Animated:     edge.Animated,
+		Style:        RawJSONModel(edge.Style),
+		Data:         RawJSONModel(edge.Data),
+		Hidden:       edge.Hidden,
+		ZIndex:       edge.ZIndex,
+		MarkerStart:  RawJSONModel(edge.MarkerStart),
+		MarkerEnd:    RawJSONModel(edge.MarkerEnd),
+		Extra:        ExtraModel(edge.Extra),
+	}
+}
+
+func ToViewportModel(viewport domain.Viewport) ViewportModel {
+	return ViewportModel{X: viewport.X, Y: viewport.Y, Zoom: viewport.Zoom}
+}
+
+func viewportJson(viewport domain.Viewport) string {
+	return string(ToJsonB(ToViewportModel(viewport)))
+}
+
+// ToFlowChartModel flattens the tree of a flowchart into the model stored in
+// version snapshots and returned by the queries.
+func ToFlowChartModel[T any](flowChart *domain.FlowChart[T]) *FlowChartModel[T] {
+	flow := &FlowChartModel[T]{
+		ID:       flowChart.Id,
+		Title:    flowChart.Title,
+		Key:      flowChart.Key,
+		Version:  flowChart.Version,
+		Viewport: ToViewportModel(flowChart.Viewport),
+	}
+
+	for _, node := range flowChart.DrawOrder() {
+		flow.AddNode(ToNodeModel(node))
+	}
+
+	for _, edge := range flowChart.Edges {
+		flow.AddEdge(ToEdgeModel(edge))
+	}
+
+	return flow
+}
+
+// ToDomain rebuilds the tree of a flowchart from its model, inferring the root
+// the same way incoming flowcharts do.
+func (f *FlowChartModel[T]) ToDomain() (*domain.FlowChart[T], error) {
+	if err := domain.ValidateEntries(f.Nodes, f.Edges); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*domain.Node[T], 0, len(f.Nodes))
+	for _, n := range f.Nodes {
+		positionAbsolute := n.PositionAbsolute
+		if n.LegacyPositionAbsolute != nil {
+			positionAbsolute = *n.LegacyPositionAbsolute
+		}
+
+		node := domain.NewNode(n.NodeID, n.Data, domain.Position{X: n.Position.X, Y: n.Position.Y},
+			n.Width, n.Height, n.Selected, domain.Position{X: positionAbsolute.X, Y: positionAbsolute.Y}, n.Dragging, n.Type)
+		node.ParentNode = n.ParentId
+		if node.ParentNode == "" {
+			node.ParentNode = n.ParentNode
+		}
+		node.Extent = []byte(n.Extent)
+		node.ZIndex = n.ZIndex
+		node.Hidden = n.Hidden
+		node.Style = []byte(n.Style)
+		node.Extra = domain.Extra(n.Extra)
+		nodes = append(nodes, node)
+	}
+
+	edges := make([]*domain.Edge, 0, len(f.Edges))
+	for _, e := range f.Edges {
+		edge := domain.NewEdge(e.Id, e.Source, e.Target)
+		edge.SourceHandle = e.SourceHandle
+		edge.TargetHandle = e.TargetHandle
+		edge.Label = e.Label
+		edge.Type = e.Type
+		edge.Animated = e.Animated
+		edge.Style = []byte(e.Style)
+		edge.Data = []byte(e.Data)
+		edge.Hidden = e.Hidden
+		edge.ZIndex = e.ZIndex
+		edge.MarkerStart = []byte(e.MarkerStart)
+		edge.MarkerEnd = []byte(e.MarkerEnd)
+		edge.Extra = domain.Extra(e.Extra)
+		edges = append(edges, edge)
+	}
+
+	var flow *domain.FlowChart[T]
+
+	if err := domain.Validate(nodes, edges); err != nil {
+		return flow, err
+	}
+
+	nodeMap := make(map[string]*domain.Node[T], len(nodes))
+	for _, node := range nodes {
+		nodeMap[node.NodeID] = node
+	}
+
+	root := domain.FindRoot(nodes, edges)
+	domain.BuildTree(root, nodeMap, edges)
+
+	viewport := domain.Viewport{X: f.Viewport.X, Y: f.Viewport.Y, Zoom: f.Viewport.Zoom}
+	if viewport.Zoom == 0 {
+		viewport = domain.DefaultViewport
+	}
+
+	return &domain.FlowChart[T]{Id: f.ID, Title: f.Title, Key: f.Key, Node: root, Edges: edges, Viewport: viewport, Version: f.Version}, nil
+}
@@ -0,0 +1,288 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"flowChart/domain"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryFlowChartStore keeps flowcharts and their versions in process memory.
+// Every aggregate built on the same store sees the same flowcharts, whatever
+// type it decodes the node data into.
+type MemoryFlowChartStore struct {
+	mu         sync.RWMutex
+	lastID     int
+	flowCharts map[string]*memoryFlowChart
+}
+
+type memoryFlowChart struct {
+	summary  FlowChartSummaryModel
+	head     []byte
+	versions []*memoryVersion
+}
+
+type memoryVersion struct {
+	VersionModel
+	snapshot []byte
+}
+
+func NewMemoryFlowChartStore() *MemoryFlowChartStore {
+	return &MemoryFlowChartStore{
+		flowCharts: map[string]*memoryFlowChart{},
+	}
+}
+
+type MemoryFlowChartAggregate[T any] struct {
+	store *MemoryFlowChartStore
+}
+
+func NewMemoryFlowChartAggregate[T any](store *MemoryFlowChartStore) *MemoryFlowChartAggregate[T] {
+	return &MemoryFlowChartAggregate[T]{
+		store: store,
+	}
+}
+
+func (r *MemoryFlowChartAggregate[T]) StoreFlowChart(ctx context.Context, flowChart *domain.FlowChart[T]) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.flowCharts[flowChart.Key]; ok {
+		return fmt.Errorf("error storing a flowchart: key %q: %w", flowChart.Key, domain.ErrFlowChartExists)
+	}
+
+	r.store.lastID++
+	now := time.Now()
+	entry := &memoryFlowChart{
+		summary: FlowChartSummaryModel{
+			ID:        strconv.Itoa(r.store.lastID),
+			Key:       flowChart.Key,
+			CreatedAt: now,
+		},
+	}
+
+	if err := r.createVersion(entry, flowChart, now); err != nil {
+		return err
+	}
+
+	r.store.flowCharts[flowChart.Key] = entry
+
+	return nil
+}
+
+func (r *MemoryFlowChartAggregate[T]) UpdateFlowChart(ctx context.Context, flowChart *domain.FlowChart[T]) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry, ok := r.store.flowCharts[flowChart.Key]
+
+	if !ok {
+		return domain.ErrFlowChartNotFound
+	}
+
+	current := entry.summary.Version
+	if err := flowChart.CheckVersion(current, true); err != nil {
+		return err
+	}
+
+	return r.createVersion(entry, flowChart, time.Now())
+}
+
+// UpdateNode stores the flowchart holding a node that changed in place. Without
+// newVersion the head is rewritten at its current version, which suits changes
+// to the layout only.
+func (r *MemoryFlowChartAggregate[T]) UpdateNode(ctx context.Context, flowChart *domain.FlowChart[T], node *domain.Node[T], newVersion bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry, ok := r.store.flowCharts[flowChart.Key]
+
+	if !ok {
+		return domain.ErrFlowChartNotFound
+	}
+
+	if err := flowChart.CheckVersion(entry.summary.Version, true); err != nil {
+		return err
+	}
+
+	if newVersion {
+		return r.createVersion(entry, flowChart, time.Now())
+	}
+
+	return r.writeHead(entry, flowChart, entry.summary.Version, time.Now())
+}
+
+// createVersion moves the head of the flowchart to a new version and keeps an
+// immutable snapshot of what was saved. The caller holds the write lock.
+func (r *MemoryFlowChartAggregate[T]) createVersion(entry *memoryFlowChart, flowChart *domain.FlowChart[T], now time.Time) error {
+	if err := r.writeHead(entry, flowChart, entry.summary.Version+1, now); err != nil {
+		return err
+	}
+
+	entry.versions = append(entry.versions, &memoryVersion{
+		VersionModel: VersionModel{
+			Version:   flowChart.Version,
+			Author:    flowChart.Author,
+			Message:   flowChart.Message,
+			CreatedAt: now,
+		},
+		snapshot: entry.head,
+	})
+
+	return nil
+}
+
+// writeHead stores the flowchart as the head at the given version. The caller
+// holds the write lock.
+func (r *MemoryFlowChartAggregate[T]) writeHead(entry *memoryFlowChart, flowChart *domain.FlowChart[T], version int, now time.Time) error {
+	flowChart.Id = entry.summary.ID
+	flowChart.Version = version
+
+	head, err := json.Marshal(ToFlowChartModel(flowChart))
+
+	if err != nil {
+		flowChart.Version = entry.summary.Version
+		return fmt.Errorf("error encoding flowchart snapshot: %w", err)
+	}
+
+	entry.summary.Title = flowChart.Title
+	entry.summary.Version = flowChart.Version
+	entry.summary.UpdatedAt = now
+	entry.head = head
+
+	return nil
+}
+
+func (r *MemoryFlowChartAggregate[T]) FlowChartExists(ctx context.Context, flowChart *domain.FlowChart[T]) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entry, ok := r.store.flowCharts[flowChart.Key]
+
+	if !ok {
+		return false, nil
+	}
+
+	flowChart.Id = entry.summary.ID
+
+	return true, nil
+}
+
+func (r *MemoryFlowChartAggregate[T]) GetFlowChart(ctx context.Context, key string) (*FlowChartModel[T], error) {
+	flow := &FlowChartModel[T]{}
+
+	r.store.mu.RLock()
+	entry, ok := r.store.flowCharts[key]
+	var head []byte
+	if ok {
+		head = entry.head
+	}
+	r.store.mu.RUnlock()
+
+	if !ok {
+		return flow, domain.ErrFlowChartNotFound
+	}
+
+	if err := json.Unmarshal(head, flow); err != nil {
+		return flow, fmt.Errorf("error decoding flowchart: %w", err)
+	}
+
+	return flow, nil
+}
+
+// LoadFlowChart reads a stored flowchart back into its domain tree.
+func (r *MemoryFlowChartAggregate[T]) LoadFlowChart(ctx context.Context, key string) (*domain.FlowChart[T], error) {
+	flow, err := r.GetFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return flow.ToDomain()
+}
+
+func (r *MemoryFlowChartAggregate[T]) DeleteFlowChart(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.flowCharts[key]; !ok {
+		return domain.ErrFlowChartNotFound
+	}
+
+	delete(r.store.flowCharts, key)
+
+	return nil
+}
+
+func (r *MemoryFlowChartAggregate[T]) ListFlowCharts(ctx context.Context, limit int, offset int) (*FlowChartPageModel, error) {
+	page := &FlowChartPageModel{Items: []*FlowChartSummaryModel{}, Limit: limit, Offset: offset}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	keys := make([]string, 0, len(r.store.flowCharts))
+	for key := range r.store.flowCharts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	page.Total = len(keys)
+
+	for i := offset; i < len(keys) && i < offset+limit; i++ {
+		summary := r.store.flowCharts[keys[i]].summary
+		page.Items = append(page.Items, &summary)
+	}
+
+	return page, nil
+}
+
+func (r *MemoryFlowChartAggregate[T]) ListVersions(ctx context.Context, key string) ([]*VersionModel, error) {
+	versions := []*VersionModel{}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entry, ok := r.store.flowCharts[key]
+
+	if !ok {
+		return versions, nil
+	}
+
+	for i := len(entry.versions) - 1; i >= 0; i-- {
+		version := entry.versions[i].VersionModel
+		versions = append(versions, &version)
+	}
+
+	return versions, nil
+}
+
+func (r *MemoryFlowChartAggregate[T]) GetVersion(ctx context.Context, key string, version int) (*FlowChartModel[T], error) {
+	flow := &FlowChartModel[T]{}
+
+	r.store.mu.RLock()
+	var snapshot []byte
+	if entry, ok := r.store.flowCharts[key]; ok {
+		// Versions that changed only the layout have no snapshot, so they are
+		// not found by their position.
+		for _, stored := range entry.versions {
+			if stored.Version == version {
+				snapshot = stored.snapshot
+			}
+		}
+	}
+	r.store.mu.RUnlock()
+
+	if snapshot == nil {
+		return flow, domain.ErrVersionNotFound
+	}
+
+	if err := json.Unmarshal(snapshot, flow); err != nil {
+		return flow, fmt.Errorf("error decoding flowchart snapshot: %w", err)
+	}
+
+	return flow, nil
+}
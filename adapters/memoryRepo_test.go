@@ -0,0 +1,172 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"flowChart/domain"
+	"testing"
+)
+
+// newFlowChart returns the flowchart start -> end under the given key.
+func newFlowChart(key string) *domain.FlowChart[domain.UnstructuredDataDomain] {
+	start := &domain.Node[domain.UnstructuredDataDomain]{NodeID: "start", Type: domain.InputNodeType, Data: map[string]any{"label": "Start"}}
+	start.AddChild(&domain.Node[domain.UnstructuredDataDomain]{NodeID: "end", Data: map[string]any{"label": "End"}})
+
+	return &domain.FlowChart[domain.UnstructuredDataDomain]{
+		Key:   key,
+		Title: "Flow " + key,
+		Node:  start,
+		Edges: []*domain.Edge{domain.NewEdge(domain.EdgeID("start", "end"), "start", "end")},
+	}
+}
+
+func expect(version int) *int {
+	return &version
+}
+
+func TestMemoryStoreFlowChart(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryFlowChartAggregate[domain.UnstructuredDataDomain](NewMemoryFlowChartStore())
+
+	if err := repo.StoreFlowChart(ctx, newFlowChart("order")); err != nil {
+		t.Fatalf("StoreFlowChart() error = %v", err)
+	}
+
+	loaded, err := repo.LoadFlowChart(ctx, "order")
+	if err != nil {
+		t.Fatalf("LoadFlowChart() error = %v", err)
+	}
+
+	if loaded.Version != 1 || loaded.Title != "Flow order" || loaded.Node.NodeID != "start" || len(loaded.Edges) != 1 {
+		t.Errorf("loaded version %d titled %q rooted at %q with %d edges, want the stored flowchart at version 1",
+			loaded.Version, loaded.Title, loaded.Node.NodeID, len(loaded.Edges))
+	}
+
+	if err := repo.StoreFlowChart(ctx, newFlowChart("order")); !errors.Is(err, domain.ErrFlowChartExists) {
+		t.Errorf("StoreFlowChart() of a taken key error = %v, want %v", err, domain.ErrFlowChartExists)
+	}
+}
+
+func TestMemoryUpdateFlowChart(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryFlowChartAggregate[domain.UnstructuredDataDomain](NewMemoryFlowChartStore())
+
+	if err := repo.UpdateFlowChart(ctx, newFlowChart("order")); !errors.Is(err, domain.ErrFlowChartNotFound) {
+		t.Errorf("UpdateFlowChart() of a missing flowchart error = %v, want %v", err, domain.ErrFlowChartNotFound)
+	}
+
+	if err := repo.StoreFlowChart(ctx, newFlowChart("order")); err != nil {
+		t.Fatalf("StoreFlowChart() error = %v", err)
+	}
+
+	update := newFlowChart("order")
+	update.Title = "Renamed"
+	update.ExpectedVersion = expect(1)
+	update.Author = "ana"
+
+	if err := repo.UpdateFlowChart(ctx, update); err != nil {
+		t.Fatalf("UpdateFlowChart() error = %v", err)
+	}
+
+	stale := newFlowChart("order")
+	stale.ExpectedVersion = expect(1)
+
+	var conflict *domain.VersionConflictError
+	if err := repo.UpdateFlowChart(ctx, stale); !errors.As(err, &conflict) || conflict.Current != 2 {
+		t.Errorf("UpdateFlowChart() of a stale version error = %v, want a conflict at version 2", err)
+	}
+
+	versions, err := repo.ListVersions(ctx, "order")
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+
+	if len(versions) != 2 || versions[0].Version != 2 || versions[0].Author != "ana" {
+		t.Errorf("versions = %+v, want 2 then 1", versions)
+	}
+
+	first, err := repo.GetVersion(ctx, "order", 1)
+	if err != nil || first.Title != "Flow order" {
+		t.Errorf("GetVersion(1) = %q, %v, want the title before the rename", first.Title, err)
+	}
+}
+
+func TestMemoryUpdateNodeLayoutKeepsVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryFlowChartAggregate[domain.UnstructuredDataDomain](NewMemoryFlowChartStore())
+
+	flowChart := newFlowChart("order")
+	if err := repo.StoreFlowChart(ctx, flowChart); err != nil {
+		t.Fatalf("StoreFlowChart() error = %v", err)
+	}
+
+	node, _ := flowChart.FindByID("end")
+	node.Position = domain.Position{X: 10, Y: 20}
+
+	if err := repo.UpdateNode(ctx, flowChart, node, false); err != nil {
+		t.Fatalf("UpdateNode() error = %v", err)
+	}
+
+	if flowChart.Version != 1 {
+		t.Errorf("version = %d, want a layout change to keep version 1", flowChart.Version)
+	}
+
+	versions, err := repo.ListVersions(ctx, "order")
+	if err != nil || len(versions) != 1 {
+		t.Errorf("ListVersions() = %d versions, %v, want only version 1", len(versions), err)
+	}
+
+	loaded, err := repo.LoadFlowChart(ctx, "order")
+	if err != nil {
+		t.Fatalf("LoadFlowChart() error = %v", err)
+	}
+
+	moved, _ := loaded.FindByID("end")
+	if loaded.Version != 1 || moved.Position != (domain.Position{X: 10, Y: 20}) {
+		t.Errorf("head at version %d with end at %v, want version 1 holding the new position", loaded.Version, moved.Position)
+	}
+
+	node.Data = map[string]any{"label": "Finish"}
+	if err := repo.UpdateNode(ctx, flowChart, node, true); err != nil {
+		t.Fatalf("UpdateNode() error = %v", err)
+	}
+
+	if _, err := repo.GetVersion(ctx, "order", 2); err != nil {
+		t.Errorf("GetVersion(2) after a data change error = %v, want its snapshot", err)
+	}
+}
+
+func TestMemoryListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryFlowChartStore()
+	repo := NewMemoryFlowChartAggregate[domain.UnstructuredDataDomain](store)
+
+	for _, key := range []string{"c", "a", "b"} {
+		if err := repo.StoreFlowChart(ctx, newFlowChart(key)); err != nil {
+			t.Fatalf("StoreFlowChart(%q) error = %v", key, err)
+		}
+	}
+
+	page, err := repo.ListFlowCharts(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("ListFlowCharts() error = %v", err)
+	}
+
+	if page.Total != 3 || len(page.Items) != 2 || page.Items[0].Key != "b" || page.Items[1].Key != "c" {
+		t.Errorf("page = %d items of %d starting at %q, want b and c of 3", len(page.Items), page.Total, page.Items[0].Key)
+	}
+
+	// Aggregates over the same store share their flowcharts.
+	other := NewMemoryFlowChartAggregate[map[string]any](store)
+	if err := other.DeleteFlowChart(ctx, "a"); err != nil {
+		t.Fatalf("DeleteFlowChart() error = %v", err)
+	}
+
+	if _, err := repo.GetFlowChart(ctx, "a"); !errors.Is(err, domain.ErrFlowChartNotFound) {
+		t.Errorf("GetFlowChart() of a deleted flowchart error = %v, want %v", err, domain.ErrFlowChartNotFound)
+	}
+
+	if err := repo.DeleteFlowChart(ctx, "a"); !errors.Is(err, domain.ErrFlowChartNotFound) {
+		t.Errorf("DeleteFlowChart() twice error = %v, want %v", err, domain.ErrFlowChartNotFound)
+	}
+}
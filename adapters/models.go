@@ -3,6 +3,9 @@ package adapters
 import (
 	"encoding/json"
 	"errors"
+	"flowChart/domain"
+	"flowChart/transport"
+	"time"
 )
 
 type PositionModel struct {
@@ -19,63 +22,211 @@ func (p *PositionModel) Scan(value any) error {
 	return json.Unmarshal(b, &p)
 }
 
+// RawJSONModel keeps a JSON document exactly as it was received.
+type RawJSONModel json.RawMessage
+
+func (j *RawJSONModel) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []uint8:
+		*j = append(RawJSONModel(nil), v...)
+	case string:
+		*j = RawJSONModel(v)
+	default:
+		return errors.New("type assertion to []uint8 failed")
+	}
+
+	return nil
+}
+
+func (j RawJSONModel) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *RawJSONModel) UnmarshalJSON(b []byte) error {
+	*j = append((*j)[0:0], b...)
+	return nil
+}
+
+// ExtraModel keeps the fields of a node or an edge that have no column of
+// their own, stored as a single JSON object.
+type ExtraModel map[string]json.RawMessage
+
+func (e *ExtraModel) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []uint8:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return errors.New("type assertion to []uint8 failed")
+	}
+}
+
 type EdgeModel struct {
-	Id     int    `json:"id"`
-	Source string `json:"source"`
-	Target string `json:"target"`
+	Id           string       `json:"id" db:"internal_id"`
+	Source       string       `json:"source" db:"source"`
+	Target       string       `json:"target" db:"target"`
+	SourceHandle *string      `json:"sourceHandle" db:"source_handle"`
+	TargetHandle *string      `json:"targetHandle" db:"target_handle"`
+	Label        string       `json:"label,omitempty" db:"label"`
+	Type         string       `json:"type,omitempty" db:"type"`
+	Animated     *bool        `json:"animated,omitempty" db:"animated"`
+	Style        RawJSONModel `json:"style,omitempty" db:"style"`
+	Data         RawJSONModel `json:"data,omitempty" db:"data"`
+	Hidden       bool         `json:"hidden,omitempty" db:"hidden"`
+	ZIndex       *int         `json:"zIndex,omitempty" db:"z_index"`
+	MarkerStart  RawJSONModel `json:"markerStart,omitempty" db:"marker_start"`
+	MarkerEnd    RawJSONModel `json:"markerEnd,omitempty" db:"marker_end"`
+	Extra        ExtraModel   `json:"-" db:"extra"`
 }
 
+type plainEdgeModel EdgeModel
+
+func (e EdgeModel) MarshalJSON() ([]byte, error) {
+	return transport.JoinExtra(plainEdgeModel(e), domain.Extra(e.Extra))
+}
+
+func (e *EdgeModel) UnmarshalJSON(data []byte) error {
+	extra, err := transport.SplitExtra(data, (*plainEdgeModel)(e))
+	e.Extra = ExtraModel(extra)
+	return err
+}
+
+// NodeModel follows the node objects of React Flow. ParentID is the parent in
+// the tree, used only to derive edges for flowcharts saved before edges were
+// stored; ParentNode and ParentId both hold the React Flow group parent.
 type NodeModel[T any] struct {
 	NodeID           string        `json:"id" db:"internal_id"`
-	ParentID         string        `json:"parentID" db:"parent_id"`
+	ParentID         string        `json:"-" db:"parent_id"`
 	Position         PositionModel `json:"position" db:"position"`
 	Data             T             `json:"data" db:"data"`
-	Width            int16         `json:"width" db:"width"`
-	Height           int16         `json:"height" db:"height"`
+	Width            float64       `json:"width" db:"width"`
+	Height           float64       `json:"height" db:"height"`
 	Selected         bool          `json:"selected" db:"selected"`
-	PositionAbsolute PositionModel `json:"position_absolute" db:"position_absolute"`
+	PositionAbsolute PositionModel `json:"positionAbsolute" db:"position_absolute"`
 	Dragging         bool          `json:"dragging" db:"dragging"`
 	Type             string        `json:"type" db:"type"`
+	ParentNode       string        `json:"parentNode,omitempty" db:"parent_node"`
+	ParentId         string        `json:"parentId,omitempty" db:"-"`
+	Extent           RawJSONModel  `json:"extent,omitempty" db:"extent"`
+	ZIndex           *int          `json:"zIndex,omitempty" db:"z_index"`
+	Hidden           bool          `json:"hidden,omitempty" db:"hidden"`
+	Style            RawJSONModel  `json:"style,omitempty" db:"style"`
+	Extra            ExtraModel    `json:"-" db:"extra"`
+
+	// LegacyParentID takes the tree parent out of version snapshots saved
+	// before the model followed React Flow, which would otherwise be read as
+	// parentId. LegacyPositionAbsolute does the same for position_absolute.
+	LegacyParentID         string         `json:"parentID,omitempty" db:"-"`
+	LegacyPositionAbsolute *PositionModel `json:"position_absolute,omitempty" db:"-"`
 }
 
-type FlowChartModel[T any] struct {
-	ID    string          `json:"id" db:"id"`
-	Title string          `json:"title" db:"title"`
-	Key   string          `json:"key" db:"key"`
-	Nodes []*NodeModel[T] `json:"nodes"`
-	Edges []*EdgeModel    `json:"Edges"`
+type plainNodeModel[T any] NodeModel[T]
+
+func (n NodeModel[T]) MarshalJSON() ([]byte, error) {
+	return transport.JoinExtra(plainNodeModel[T](n), domain.Extra(n.Extra))
 }
 
-func (f *FlowChartModel[T]) AddNode(node *NodeModel[T]) {
-	f.Nodes = append(f.Nodes, node)
-	edge := f.createEdge(node)
-	if edge != nil {
-		f.Edges = append(f.Edges, edge)
-	}
+func (n *NodeModel[T]) UnmarshalJSON(data []byte) error {
+	extra, err := transport.SplitExtra(data, (*plainNodeModel[T])(n))
+	n.Extra = ExtraModel(extra)
+	return err
+}
 
+// FlowChartModel is the object React Flow saves with toObject(), plus the
+// identity and version of the flowchart.
+type FlowChartModel[T any] struct {
+	ID       string          `json:"id" db:"id"`
+	Title    string          `json:"title" db:"title"`
+	Key      string          `json:"key" db:"key"`
+	Version  int             `json:"version" db:"version"`
+	Nodes    []*NodeModel[T] `json:"nodes"`
+	Edges    []*EdgeModel    `json:"edges"`
+	Viewport ViewportModel   `json:"viewport" db:"viewport"`
 }
 
-func (f *FlowChartModel[T]) createEdge(node *NodeModel[T]) *EdgeModel {
+type ViewportModel struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Zoom float64 `json:"zoom"`
+}
 
-	if node.NodeID == node.ParentID {
+// Scan reads a stored viewport, or the one React Flow starts with when none
+// was saved.
+func (v *ViewportModel) Scan(value any) error {
+	switch b := value.(type) {
+	case nil:
+		*v = ViewportModel{Zoom: 1}
 		return nil
+	case []uint8:
+		return json.Unmarshal(b, v)
+	case string:
+		return json.Unmarshal([]byte(b), v)
+	default:
+		return errors.New("type assertion to []uint8 failed")
 	}
+}
 
-	return &EdgeModel{
-		Id:     len(f.Edges) + 1,
-		Source: node.NodeID,
-		Target: node.ParentID,
-	}
+type FlowChartSummaryModel struct {
+	ID        string    `json:"id" db:"id"`
+	Title     string    `json:"title" db:"title"`
+	Key       string    `json:"key" db:"key"`
+	Version   int       `json:"version" db:"version"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
 
+type FlowChartPageModel struct {
+	Items  []*FlowChartSummaryModel `json:"items"`
+	Total  int                      `json:"total"`
+	Limit  int                      `json:"limit"`
+	Offset int                      `json:"offset"`
 }
 
-type WagtailDataModel []map[string]any
+type VersionModel struct {
+	Version   int       `json:"version" db:"version"`
+	Author    string    `json:"author" db:"author"`
+	Message   string    `json:"message" db:"message"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
 
-func (u *WagtailDataModel) Scan(value any) error {
-	b, ok := value.([]uint8)
-	if !ok {
-		return errors.New("type assertion to []uint8 failed")
+func (f *FlowChartModel[T]) AddNode(node *NodeModel[T]) {
+	f.Nodes = append(f.Nodes, node)
+}
+
+func (f *FlowChartModel[T]) AddEdge(edge *EdgeModel) {
+	f.Edges = append(f.Edges, edge)
+}
+
+// deriveEdges rebuilds the tree edges from the parent of each node, for
+// flowcharts saved before edges were stored.
+func (f *FlowChartModel[T]) deriveEdges() {
+	nodes := make(map[string]bool, len(f.Nodes))
+	for _, node := range f.Nodes {
+		nodes[node.NodeID] = true
 	}
 
-	return json.Unmarshal(b, &u)
+	for _, node := range f.Nodes {
+		if node.NodeID == node.ParentID || !nodes[node.ParentID] {
+			continue
+		}
+
+		f.AddEdge(&EdgeModel{
+			Id:     node.NodeID,
+			Source: node.ParentID,
+			Target: node.NodeID,
+		})
+	}
 }
+
+// WagtailDataModel keeps node data as the raw JSON it was saved with, whatever
+// its shape.
+type WagtailDataModel = RawJSONModel
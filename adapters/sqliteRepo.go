@@ -0,0 +1,104 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// maxSqliteParams keeps statements under the 32766 host parameters SQLite
+// accepts in a single statement.
+const maxSqliteParams = 32000
+
+// NewSqliteFlowChartAggregate stores flowcharts in a SQLite database created
+// from the sqlite migrations. Transactions must be opened with
+// _txlock=immediate, which takes the write lock Postgres gets from FOR UPDATE.
+func NewSqliteFlowChartAggregate[T any](db *sqlx.DB) *BaseFlowChartAggregate[T] {
+	return &BaseFlowChartAggregate[T]{
+		client:  db,
+		dialect: sqliteDialect{},
+	}
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) text(column string) string {
+	return column
+}
+
+func (sqliteDialect) forUpdate() string {
+	return ""
+}
+
+func (sqliteDialect) uniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// syncRows writes the rows one at a time through a prepared statement per kind
+// of change. SQLite stores JSON as text, so the casts are not needed.
+func (sqliteDialect) syncRows(ctx context.Context, tx *sqlx.Tx, table string, flowchartID string, columns []string, casts []string, inserts [][]any, updates [][]any, deletes []string) error {
+	for start := 0; start < len(deletes); start += maxSqliteParams {
+		end := start + maxSqliteParams
+		if end > len(deletes) {
+			end = len(deletes)
+		}
+
+		args := []any{flowchartID}
+		for _, id := range deletes[start:end] {
+			args = append(args, id)
+		}
+
+		query := fmt.Sprintf("DELETE FROM %s WHERE flowchart_id=? AND internal_id IN (?%s)", table, strings.Repeat(", ?", end-start-1))
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("error deleting from %s: %w", table, err)
+		}
+	}
+
+	if len(inserts) > 0 {
+		query := fmt.Sprintf("INSERT into %s (flowchart_id, %s) VALUES (?%s)", table, strings.Join(columns, ", "), strings.Repeat(", ?", len(columns)))
+
+		if err := execEach(ctx, tx, query, inserts, func(row []any) []any { return append([]any{flowchartID}, row...) }); err != nil {
+			return fmt.Errorf("error inserting into %s: %w", table, err)
+		}
+	}
+
+	if len(updates) > 0 {
+		assignments := make([]string, 0, len(columns))
+		for _, column := range columns[1:] {
+			assignments = append(assignments, column+" = ?")
+		}
+		assignments = append(assignments, "updated_at = CURRENT_TIMESTAMP")
+
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE flowchart_id = ? AND internal_id = ?", table, strings.Join(assignments, ", "))
+
+		if err := execEach(ctx, tx, query, updates, func(row []any) []any { return append(row[1:len(row):len(row)], flowchartID, row[0]) }); err != nil {
+			return fmt.Errorf("error updating %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+func execEach(ctx context.Context, tx *sqlx.Tx, query string, rows [][]any, args func([]any) []any) error {
+	stmt, err := tx.PrepareContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, args(row)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"flowChart/domain"
+	"flowChart/migrations"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func newSqliteAggregate(t *testing.T) *BaseFlowChartAggregate[domain.UnstructuredDataDomain] {
+	t.Helper()
+
+	dns := fmt.Sprintf("file:%s?_foreign_keys=on&_txlock=immediate", filepath.Join(t.TempDir(), "flowchart.db"))
+	db, err := sqlx.Open("sqlite3", dns)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewSqliteMigrator(db)
+	if err != nil {
+		t.Fatalf("NewSqliteMigrator() error = %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	return NewSqliteFlowChartAggregate[domain.UnstructuredDataDomain](db)
+}
+
+func TestSqliteStoreFlowChart(t *testing.T) {
+	ctx := context.Background()
+	repo := newSqliteAggregate(t)
+
+	if err := repo.StoreFlowChart(ctx, newFlowChart("order")); err != nil {
+		t.Fatalf("StoreFlowChart() error = %v", err)
+	}
+
+	loaded, err := repo.LoadFlowChart(ctx, "order")
+	if err != nil {
+		t.Fatalf("LoadFlowChart() error = %v", err)
+	}
+
+	if loaded.Node.NodeID != "start" || len(loaded.Edges) != 1 {
+		t.Errorf("loaded flowchart rooted at %q with %d edges, want start -> end", loaded.Node.NodeID, len(loaded.Edges))
+	}
+
+	if err := repo.StoreFlowChart(ctx, newFlowChart("order")); !errors.Is(err, domain.ErrFlowChartExists) {
+		t.Errorf("StoreFlowChart() of a taken key error = %v, want %v", err, domain.ErrFlowChartExists)
+	}
+}
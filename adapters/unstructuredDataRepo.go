@@ -1,27 +1,67 @@
 package adapters
 
 import (
+	"context"
 	"flowChart/domain"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// FlowChartRepository is everything the handlers need from a storage backend.
+// The SQL and the in-memory aggregates implement it.
+type FlowChartRepository[T any] interface {
+	StoreFlowChart(ctx context.Context, flowChart *domain.FlowChart[T]) error
+	UpdateFlowChart(ctx context.Context, flowChart *domain.FlowChart[T]) error
+	UpdateNode(ctx context.Context, flowChart *domain.FlowChart[T], node *domain.Node[T], newVersion bool) error
+	FlowChartExists(ctx context.Context, flowChart *domain.FlowChart[T]) (bool, error)
+	GetFlowChart(ctx context.Context, key string) (*FlowChartModel[T], error)
+	LoadFlowChart(ctx context.Context, key string) (*domain.FlowChart[T], error)
+	DeleteFlowChart(ctx context.Context, key string) error
+	ListFlowCharts(ctx context.Context, limit int, offset int) (*FlowChartPageModel, error)
+	ListVersions(ctx context.Context, key string) ([]*VersionModel, error)
+	GetVersion(ctx context.Context, key string, version int) (*FlowChartModel[T], error)
+}
+
 type WriteFlowChartUnstructuredDataAgg struct {
-	*BaseFlowChartAggregate[domain.UnstructuredDataDomain]
+	FlowChartRepository[domain.UnstructuredDataDomain]
 }
 
 func NewWriteFlowChartUnstructuredDataAgg(client *sqlx.DB) *WriteFlowChartUnstructuredDataAgg {
 	return &WriteFlowChartUnstructuredDataAgg{
-		BaseFlowChartAggregate: NewBaseFlowchartAggregate[domain.UnstructuredDataDomain](client),
+		FlowChartRepository: NewBaseFlowchartAggregate[domain.UnstructuredDataDomain](client),
+	}
+}
+
+func NewMemoryWriteFlowChartUnstructuredDataAgg(store *MemoryFlowChartStore) *WriteFlowChartUnstructuredDataAgg {
+	return &WriteFlowChartUnstructuredDataAgg{
+		FlowChartRepository: NewMemoryFlowChartAggregate[domain.UnstructuredDataDomain](store),
+	}
+}
+
+func NewSqliteWriteFlowChartUnstructuredDataAgg(client *sqlx.DB) *WriteFlowChartUnstructuredDataAgg {
+	return &WriteFlowChartUnstructuredDataAgg{
+		FlowChartRepository: NewSqliteFlowChartAggregate[domain.UnstructuredDataDomain](client),
 	}
 }
 
 type ReadFlowChartUnstructuredDataAgg struct {
-	*BaseFlowChartAggregate[WagtailDataModel]
+	FlowChartRepository[WagtailDataModel]
 }
 
 func NewReadFlowChartUnstructuredDataAgg(client *sqlx.DB) *ReadFlowChartUnstructuredDataAgg {
 	return &ReadFlowChartUnstructuredDataAgg{
-		BaseFlowChartAggregate: NewBaseFlowchartAggregate[WagtailDataModel](client),
+		FlowChartRepository: NewBaseFlowchartAggregate[WagtailDataModel](client),
+	}
+}
+
+func NewMemoryReadFlowChartUnstructuredDataAgg(store *MemoryFlowChartStore) *ReadFlowChartUnstructuredDataAgg {
+	return &ReadFlowChartUnstructuredDataAgg{
+		FlowChartRepository: NewMemoryFlowChartAggregate[WagtailDataModel](store),
+	}
+}
+
+func NewSqliteReadFlowChartUnstructuredDataAgg(client *sqlx.DB) *ReadFlowChartUnstructuredDataAgg {
+	return &ReadFlowChartUnstructuredDataAgg{
+		FlowChartRepository: NewSqliteFlowChartAggregate[WagtailDataModel](client),
 	}
 }
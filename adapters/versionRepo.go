@@ -0,0 +1,121 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flowChart/domain"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// createVersion moves the head of the flowchart to a new version and keeps an
+// immutable snapshot of what was saved.
+func (r *BaseFlowChartAggregate[T]) createVersion(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	if err := r.moveHead(ctx, tx, flowChart); err != nil {
+		return err
+	}
+
+	snapshot, err := json.Marshal(ToFlowChartModel(flowChart))
+
+	if err != nil {
+		return fmt.Errorf("error encoding flowchart snapshot: %w", err)
+	}
+
+	query := `INSERT into flowchart_version (flowchart_id, version, author, message, snapshot)
+	 VALUES (?, ?, ?, ?, ?)`
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), flowChart.Id, flowChart.Version, flowChart.Author, flowChart.Message, string(snapshot)); err != nil {
+		return fmt.Errorf("error creating a flowchart version: %w", err)
+	}
+
+	return nil
+}
+
+// moveHead gives the flowchart its next version number. createVersion keeps
+// the snapshot of it.
+func (r *BaseFlowChartAggregate[T]) moveHead(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	query := `UPDATE flowchart SET version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id=? RETURNING version`
+
+	if err := tx.QueryRowContext(ctx, tx.Rebind(query), flowChart.Id).Scan(&flowChart.Version); err != nil {
+		return fmt.Errorf("error moving flowchart head version: %w", err)
+	}
+
+	return nil
+}
+
+// touchHead marks the flowchart as updated while keeping it at its current
+// version, for changes that are not kept in the history.
+func (r *BaseFlowChartAggregate[T]) touchHead(ctx context.Context, tx *sqlx.Tx, flowChart *domain.FlowChart[T]) error {
+	query := `UPDATE flowchart SET updated_at = CURRENT_TIMESTAMP WHERE id=? RETURNING version`
+
+	if err := tx.QueryRowContext(ctx, tx.Rebind(query), flowChart.Id).Scan(&flowChart.Version); err != nil {
+		return fmt.Errorf("error updating flowchart head: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BaseFlowChartAggregate[T]) ListVersions(ctx context.Context, key string) ([]*VersionModel, error) {
+	query := `
+	SELECT
+		version.version,
+		version.author,
+		version.message,
+		version.created_at
+	FROM
+		flowchart_version as version
+	JOIN
+		flowchart
+	ON
+		flowchart.id = version.flowchart_id
+	WHERE
+		flowchart.key = ?
+	ORDER BY
+		version.version DESC
+	`
+
+	versions := []*VersionModel{}
+
+	if err := r.client.SelectContext(ctx, &versions, r.client.Rebind(query), key); err != nil {
+		return versions, fmt.Errorf("error querying flowchart versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+func (r *BaseFlowChartAggregate[T]) GetVersion(ctx context.Context, key string, version int) (*FlowChartModel[T], error) {
+	query := `
+	SELECT
+		version.snapshot
+	FROM
+		flowchart_version as version
+	JOIN
+		flowchart
+	ON
+		flowchart.id = version.flowchart_id
+	WHERE
+		flowchart.key = ? AND version.version = ?
+	`
+
+	flow := &FlowChartModel[T]{}
+	var snapshot []byte
+
+	err := r.client.QueryRowContext(ctx, r.client.Rebind(query), key, version).Scan(&snapshot)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return flow, domain.ErrVersionNotFound
+	}
+
+	if err != nil {
+		return flow, fmt.Errorf("error querying a flowchart version: %w", err)
+	}
+
+	if err := json.Unmarshal(snapshot, flow); err != nil {
+		return flow, fmt.Errorf("error decoding flowchart snapshot: %w", err)
+	}
+
+	return flow, nil
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Edge connects two nodes of a flowchart. Edges are kept even when they do not
+// belong to the tree formed by the nodes, such as merges into a node that
+// already has a parent or loops back to an earlier node.
+//
+// Optional fields are pointers or raw JSON so that a missing value is told
+// apart from a zero one when the edge is sent back to React Flow.
+type Edge struct {
+	ID           string
+	Source       string
+	Target       string
+	SourceHandle *string
+	TargetHandle *string
+	Label        string
+	Type         string
+	Animated     *bool
+	Style        json.RawMessage
+	Data         json.RawMessage
+	Hidden       bool
+	ZIndex       *int
+	MarkerStart  json.RawMessage
+	MarkerEnd    json.RawMessage
+	Extra        Extra
+}
+
+func NewEdge(id string, source string, target string) *Edge {
+	return &Edge{
+		ID:     id,
+		Source: source,
+		Target: target,
+	}
+}
+
+// EdgeID builds an edge id the same way React Flow does when connecting nodes.
+func EdgeID(source string, target string) string {
+	return fmt.Sprintf("reactflow__edge-%s-%s", source, target)
+}
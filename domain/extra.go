@@ -0,0 +1,10 @@
+package domain
+
+import (
+	"encoding/json"
+)
+
+// Extra holds the fields React Flow sends with a node or an edge that the
+// flowchart does not use itself, such as className or measured, so they are
+// sent back as they came.
+type Extra map[string]json.RawMessage
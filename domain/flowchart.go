@@ -1,8 +1,354 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrFlowChartNotFound = errors.New("flowchart not found")
+	ErrVersionNotFound   = errors.New("flowchart version not found")
+	ErrFlowChartExists   = errors.New("flowchart already exists")
+
+	// ErrPreconditionFailed means the change was only to be saved over an
+	// existing flowchart, and there is none.
+	ErrPreconditionFailed = errors.New("flowchart does not exist")
+)
+
+// AnyVersion is the expected version of a change that may be saved over any
+// version of the flowchart, as long as it exists.
+const AnyVersion = -1
+
+// VersionConflictError means the flowchart was saved by someone else after the
+// version a change was based on.
+type VersionConflictError struct {
+	Key      string
+	Expected int
+	Current  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("flowchart %q is at version %d, not %d", e.Key, e.Current, e.Expected)
+}
+
+// Viewport is the pan and zoom the flowchart was last shown with.
+type Viewport struct {
+	X    float64
+	Y    float64
+	Zoom float64
+}
+
+// DefaultViewport is the viewport React Flow starts with.
+var DefaultViewport = Viewport{X: 0, Y: 0, Zoom: 1}
+
 type FlowChart[T any] struct {
-	Id    string
-	Title string
-	Key   string
-	Node  *Node[T]
+	Id       string
+	Title    string
+	Key      string
+	Node     *Node[T]
+	Edges    []*Edge
+	Viewport Viewport
+
+	// Version is the number of the head version, set every time the flowchart
+	// is saved. Author and Message describe the change being saved.
+	Version int
+	Author  string
+	Message string
+
+	// ExpectedVersion is the head version the change was based on. Saving fails
+	// with a VersionConflictError when the head moved since then; nil skips the
+	// check. A flowchart saved before versioning, or not saved yet, is at 0.
+	ExpectedVersion *int
+
+	index       map[string]*Node[T]
+	indexedRoot *Node[T]
+}
+
+// CheckVersion makes sure the change can be saved over the stored flowchart,
+// whose head is at version current when it exists.
+func (f *FlowChart[T]) CheckVersion(current int, exists bool) error {
+	if f.ExpectedVersion == nil {
+		return nil
+	}
+
+	expected := *f.ExpectedVersion
+
+	if expected == AnyVersion {
+		if !exists {
+			return ErrPreconditionFailed
+		}
+		return nil
+	}
+
+	if expected != current {
+		return &VersionConflictError{Key: f.Key, Expected: expected, Current: current}
+	}
+
+	return nil
+}
+
+// Reindex rebuilds the NodeID index from the tree. The FlowChart methods keep
+// the index up to date, and a root set through Node is picked up on the next
+// lookup; a tree changed through the Node methods must be reindexed.
+func (f *FlowChart[T]) Reindex() {
+	f.index = make(map[string]*Node[T])
+	f.indexedRoot = f.Node
+
+	f.Node.Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[T]) bool {
+		f.indexNode(n)
+		return false
+	})
+}
+
+func (f *FlowChart[T]) indexNode(node *Node[T]) {
+	if _, ok := f.index[node.NodeID]; !ok {
+		f.index[node.NodeID] = node
+	}
+}
+
+func (f *FlowChart[T]) indexSubtree(node *Node[T]) {
+	node.Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[T]) bool {
+		f.indexNode(n)
+		return false
+	})
+}
+
+func (f *FlowChart[T]) unindexSubtree(node *Node[T]) {
+	node.Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[T]) bool {
+		if f.index[n.NodeID] == n {
+			delete(f.index, n.NodeID)
+		}
+		return false
+	})
+}
+
+func (f *FlowChart[T]) ensureIndex() {
+	if f.index == nil || f.indexedRoot != f.Node {
+		f.Reindex()
+	}
+}
+
+// FindByID looks the node up in the index, which is only built again when the
+// root of the flowchart was replaced.
+func (f *FlowChart[T]) FindByID(id string) (*Node[T], bool) {
+	f.ensureIndex()
+
+	node, ok := f.index[id]
+	return node, ok
+}
+
+func (f *FlowChart[T]) FindAll(predicate func(*Node[T]) bool) []*Node[T] {
+	var nodes []*Node[T]
+
+	f.Node.Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[T]) bool {
+		if predicate(n) {
+			nodes = append(nodes, n)
+		}
+		return false
+	})
+
+	return nodes
+}
+
+func (f *FlowChart[T]) Children(id string) []*Node[T] {
+	node, ok := f.FindByID(id)
+	if !ok {
+		return nil
+	}
+
+	var children []*Node[T]
+
+	node.Traverse(TraverseLevelOrder, TraverseAll, 2, func(n *Node[T]) bool {
+		if n != node {
+			children = append(children, n)
+		}
+		return false
+	})
+
+	return children
+}
+
+// Ancestors returns the ancestors of the node, starting from its parent.
+func (f *FlowChart[T]) Ancestors(id string) []*Node[T] {
+	node, ok := f.FindByID(id)
+	if !ok {
+		return nil
+	}
+
+	var ancestors []*Node[T]
+	for parent := node.parent; parent != nil; parent = parent.parent {
+		ancestors = append(ancestors, parent)
+	}
+
+	return ancestors
+}
+
+func (f *FlowChart[T]) Descendants(id string) []*Node[T] {
+	node, ok := f.FindByID(id)
+	if !ok {
+		return nil
+	}
+
+	var descendants []*Node[T]
+
+	node.Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[T]) bool {
+		if n != node {
+			descendants = append(descendants, n)
+		}
+		return false
+	})
+
+	return descendants
+}
+
+func (f *FlowChart[T]) Siblings(id string) []*Node[T] {
+	node, ok := f.FindByID(id)
+	if !ok || node.parent == nil {
+		return nil
+	}
+
+	var siblings []*Node[T]
+	for _, child := range f.Children(node.parent.NodeID) {
+		if child != node {
+			siblings = append(siblings, child)
+		}
+	}
+
+	return siblings
+}
+
+// PathTo returns the nodes from the root down to the node itself.
+func (f *FlowChart[T]) PathTo(id string) []*Node[T] {
+	node, ok := f.FindByID(id)
+	if !ok {
+		return nil
+	}
+
+	ancestors := f.Ancestors(id)
+	path := make([]*Node[T], 0, len(ancestors)+1)
+
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		path = append(path, ancestors[i])
+	}
+
+	return append(path, node)
+}
+
+// AddNode attaches a detached node under the given parent. With an empty
+// parentID the node becomes the root of an empty flowchart.
+func (f *FlowChart[T]) AddNode(parentID string, node *Node[T], index int) error {
+	f.ensureIndex()
+
+	if parentID == "" {
+		if f.Node != nil {
+			return ErrNodeAttached
+		}
+		f.Node = node
+		f.Reindex()
+		return nil
+	}
+
+	parent, ok := f.FindByID(parentID)
+	if !ok {
+		return ErrNodeNotFound
+	}
+
+	if err := parent.InsertChild(index, node); err != nil {
+		return err
+	}
+
+	if _, ok := f.FindEdge(parentID, node.NodeID); !ok {
+		f.AddEdge(NewEdge(EdgeID(parentID, node.NodeID), parentID, node.NodeID))
+	}
+
+	f.indexSubtree(node)
+	return nil
+}
+
+func (f *FlowChart[T]) RemoveNode(id string, withSubtree bool) error {
+	node, ok := f.FindByID(id)
+	if !ok {
+		return ErrNodeNotFound
+	}
+
+	root := f.Node
+	if node == root {
+		root = nil
+		if !withSubtree {
+			root = node.children
+		}
+	}
+
+	removed := map[string]bool{id: true}
+	if withSubtree {
+		for _, descendant := range f.Descendants(id) {
+			removed[descendant.NodeID] = true
+		}
+	}
+
+	parent := node.parent
+	if err := node.Remove(withSubtree); err != nil {
+		return err
+	}
+
+	if withSubtree {
+		f.unindexSubtree(node)
+	} else if f.index[id] == node {
+		delete(f.index, id)
+	}
+
+	if !withSubtree && parent != nil {
+		for _, edge := range f.OutgoingEdges(id) {
+			if _, ok := f.FindEdge(parent.NodeID, edge.Target); !ok {
+				edge.Source = parent.NodeID
+			}
+		}
+	}
+
+	f.RemoveEdges(func(edge *Edge) bool {
+		return removed[edge.Source] || removed[edge.Target]
+	})
+
+	f.Node = root
+	f.indexedRoot = root
+	return nil
+}
+
+func (f *FlowChart[T]) MoveNode(id string, parentID string, index int) error {
+	node, ok := f.FindByID(id)
+	if !ok {
+		return ErrNodeNotFound
+	}
+
+	parent, ok := f.FindByID(parentID)
+	if !ok {
+		return ErrNodeNotFound
+	}
+
+	oldParent := node.parent
+	if err := node.MoveTo(parent, index); err != nil {
+		return err
+	}
+
+	if oldParent == parent {
+		return nil
+	}
+
+	_, connected := f.FindEdge(parentID, id)
+
+	if oldParent != nil {
+		if edge, ok := f.FindEdge(oldParent.NodeID, id); ok {
+			if connected {
+				f.RemoveEdges(func(e *Edge) bool { return e == edge })
+				return nil
+			}
+			edge.Source = parentID
+			return nil
+		}
+	}
+
+	if !connected {
+		f.AddEdge(NewEdge(EdgeID(parentID, id), parentID, id))
+	}
+	return nil
 }
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+// newIndexedFlowChart returns the flowchart of the tree a(b(d, e), c).
+func newIndexedFlowChart() *FlowChart[string] {
+	return &FlowChart[string]{Node: newTree()["a"]}
+}
+
+func ids(nodes []*Node[string]) string {
+	found := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		found = append(found, node.NodeID)
+	}
+	return strings.Join(found, " ")
+}
+
+func TestFindByIDFollowsChanges(t *testing.T) {
+	flowChart := newIndexedFlowChart()
+
+	if node, ok := flowChart.FindByID("e"); !ok || node.NodeID != "e" {
+		t.Fatalf("FindByID(e) = %v, %v, want e", node, ok)
+	}
+
+	if err := flowChart.AddNode("c", &Node[string]{NodeID: "f"}, -1); err != nil {
+		t.Fatalf("AddNode() error = %v", err)
+	}
+
+	if node, ok := flowChart.FindByID("f"); !ok || node.Parent().NodeID != "c" {
+		t.Errorf("FindByID(f) after adding it = %v, %v, want f under c", node, ok)
+	}
+
+	if err := flowChart.MoveNode("b", "c", -1); err != nil {
+		t.Fatalf("MoveNode() error = %v", err)
+	}
+
+	if got := ids(flowChart.PathTo("d")); got != "a c b d" {
+		t.Errorf("PathTo(d) after moving b under c = %q", got)
+	}
+
+	if err := flowChart.RemoveNode("b", true); err != nil {
+		t.Fatalf("RemoveNode() error = %v", err)
+	}
+
+	for _, id := range []string{"b", "d", "e"} {
+		if _, ok := flowChart.FindByID(id); ok {
+			t.Errorf("FindByID(%s) found a node removed with its subtree", id)
+		}
+	}
+
+	if err := flowChart.RemoveNode("c", false); err != nil {
+		t.Fatalf("RemoveNode() error = %v", err)
+	}
+
+	if node, ok := flowChart.FindByID("f"); !ok || node.Parent().NodeID != "a" {
+		t.Errorf("FindByID(f) after removing c alone = %v, %v, want f under a", node, ok)
+	}
+}
+
+func TestFindByIDAfterRootReplaced(t *testing.T) {
+	flowChart := newIndexedFlowChart()
+
+	if _, ok := flowChart.FindByID("x"); ok {
+		t.Errorf("FindByID(x) found a node that does not exist")
+	}
+
+	flowChart.Node = &Node[string]{NodeID: "x"}
+
+	if _, ok := flowChart.FindByID("x"); !ok {
+		t.Errorf("FindByID(x) did not find the new root")
+	}
+
+	if _, ok := flowChart.FindByID("a"); ok {
+		t.Errorf("FindByID(a) found a node of the replaced tree")
+	}
+}
+
+func TestQueryHelpers(t *testing.T) {
+	flowChart := newIndexedFlowChart()
+
+	tests := []struct {
+		name string
+		got  []*Node[string]
+		want string
+	}{
+		{"children", flowChart.Children("b"), "d e"},
+		{"ancestors", flowChart.Ancestors("e"), "b a"},
+		{"descendants", flowChart.Descendants("b"), "d e"},
+		{"siblings", flowChart.Siblings("d"), "e"},
+		{"path", flowChart.PathTo("e"), "a b e"},
+		{"missing node", flowChart.Children("x"), ""},
+		{"root siblings", flowChart.Siblings("a"), ""},
+	}
+
+	for _, tt := range tests {
+		if got := ids(tt.got); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package domain
+
+import "sort"
+
+// BuildTree links nodes into a spanning tree of the graph, starting at root and
+// following the edges breadth-first. A node reached by more than one edge is
+// placed under the first parent found; the remaining edges stay in the edge
+// list only. Edges pointing to unknown nodes are ignored. Group nodes that no
+// edge reaches are placed under root, ordered by id.
+func BuildTree[T any](root *Node[T], nodes map[string]*Node[T], edges []*Edge) {
+	if root == nil {
+		return
+	}
+
+	outgoing := make(map[string][]*Edge, len(nodes))
+	for _, edge := range edges {
+		outgoing[edge.Source] = append(outgoing[edge.Source], edge)
+	}
+
+	visited := map[string]bool{root.NodeID: true}
+	queue := []*Node[T]{root}
+
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range outgoing[parent.NodeID] {
+			child, ok := nodes[edge.Target]
+			if !ok || visited[edge.Target] {
+				continue
+			}
+
+			visited[edge.Target] = true
+			parent.AddChild(child)
+			queue = append(queue, child)
+		}
+	}
+
+	all := make([]*Node[T], 0, len(nodes))
+	for _, node := range nodes {
+		all = append(all, node)
+	}
+
+	var groups []string
+	for id := range groupNodes(all) {
+		if _, ok := nodes[id]; ok && !visited[id] {
+			groups = append(groups, id)
+		}
+	}
+	sort.Strings(groups)
+
+	for _, id := range groups {
+		root.AddChild(nodes[id])
+	}
+}
+
+// DrawOrder lists the nodes in tree pre-order, except that a node drawn inside
+// a group always comes after the group, as React Flow requires.
+func (f *FlowChart[T]) DrawOrder() []*Node[T] {
+	var nodes []*Node[T]
+
+	if f.Node == nil {
+		return nodes
+	}
+
+	byID := map[string]*Node[T]{}
+	f.Node.Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[T]) bool {
+		nodes = append(nodes, n)
+		byID[n.NodeID] = n
+		return false
+	})
+
+	order := make([]*Node[T], 0, len(nodes))
+	placed := make(map[string]bool, len(nodes))
+
+	var place func(n *Node[T])
+	place = func(n *Node[T]) {
+		if placed[n.NodeID] {
+			return
+		}
+		placed[n.NodeID] = true
+
+		if group, ok := byID[n.ParentNode]; ok {
+			place(group)
+		}
+
+		order = append(order, n)
+	}
+
+	for _, node := range nodes {
+		place(node)
+	}
+
+	return order
+}
+
+func (f *FlowChart[T]) OutgoingEdges(id string) []*Edge {
+	var edges []*Edge
+	for _, edge := range f.Edges {
+		if edge.Source == id {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func (f *FlowChart[T]) IncomingEdges(id string) []*Edge {
+	var edges []*Edge
+	for _, edge := range f.Edges {
+		if edge.Target == id {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func (f *FlowChart[T]) FindEdge(source string, target string) (*Edge, bool) {
+	for _, edge := range f.Edges {
+		if edge.Source == source && edge.Target == target {
+			return edge, true
+		}
+	}
+	return nil, false
+}
+
+func (f *FlowChart[T]) FindEdgeByID(id string) (*Edge, bool) {
+	for _, edge := range f.Edges {
+		if edge.ID == id {
+			return edge, true
+		}
+	}
+	return nil, false
+}
+
+func (f *FlowChart[T]) AddEdge(edge *Edge) {
+	f.Edges = append(f.Edges, edge)
+}
+
+// RemoveEdges drops every edge for which match returns true.
+func (f *FlowChart[T]) RemoveEdges(match func(*Edge) bool) {
+	edges := f.Edges[:0]
+	for _, edge := range f.Edges {
+		if !match(edge) {
+			edges = append(edges, edge)
+		}
+	}
+	f.Edges = edges
+}
+
+// Relink validates the graph made of the nodes in the tree and every edge, then
+// builds the tree again from it. The tree only follows the edges when it is
+// built, so this is needed after edges are added, removed or reconnected.
+func (f *FlowChart[T]) Relink() error {
+	nodes := f.FindAll(func(*Node[T]) bool { return true })
+
+	if err := Validate(nodes, f.Edges); err != nil {
+		return err
+	}
+
+	byID := make(map[string]*Node[T], len(nodes))
+	for _, node := range nodes {
+		node.parent = nil
+		node.children = nil
+		node.next = nil
+		node.previous = nil
+		byID[node.NodeID] = node
+	}
+
+	f.Node = FindRoot(nodes, f.Edges)
+	BuildTree(f.Node, byID, f.Edges)
+	f.Reindex()
+
+	return nil
+}
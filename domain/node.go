@@ -3,9 +3,26 @@ package domain
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+var (
+	ErrNodeAttached      = errors.New("node is already attached to a tree")
+	ErrNodeWithoutParent = errors.New("node has no parent")
+	ErrNodeIsAncestor    = errors.New("node cannot be placed under itself or one of its descendants")
+	ErrIndexOutOfRange   = errors.New("child index out of range")
+	ErrRootHasSiblings   = errors.New("root children cannot be promoted to several roots")
+	ErrNodeNotFound      = errors.New("node not found")
+	ErrNilNode           = errors.New("node is nil")
+	ErrNodeExists        = errors.New("node already exists")
+	ErrEdgeNotFound      = errors.New("edge not found")
+	ErrEdgeExists        = errors.New("edge already exists")
+)
+
+// InputNodeType is the React Flow type given to the node where a flow starts.
+const InputNodeType = "input"
+
 const (
 	TraverseInOrder TraverseType = iota
 	TraversePreOrder
@@ -37,19 +54,30 @@ type Node[T any] struct {
 	NodeID           string
 	Data             T
 	Position         Position
-	Width            int16
-	Height           int16
+	Width            float64
+	Height           float64
 	Selected         bool
 	PositionAbsolute Position
 	Dragging         bool
 	Type             string
-	next             *Node[T]
-	previous         *Node[T]
-	parent           *Node[T]
-	children         *Node[T]
+
+	// ParentNode is the group node this node is drawn inside, as in React Flow
+	// sub flows. It has nothing to do with the parent in the tree. Extent and
+	// Style are kept as the raw JSON React Flow sent.
+	ParentNode string
+	Extent     json.RawMessage
+	ZIndex     *int
+	Hidden     bool
+	Style      json.RawMessage
+	Extra      Extra
+
+	next     *Node[T]
+	previous *Node[T]
+	parent   *Node[T]
+	children *Node[T]
 }
 
-func NewNode[T any](nodeID string, data T, position Position, width int16, height int16, selected bool, positionAbsolute Position, dragging bool, typeNode string) *Node[T] {
+func NewNode[T any](nodeID string, data T, position Position, width float64, height float64, selected bool, positionAbsolute Position, dragging bool, typeNode string) *Node[T] {
 	return &Node[T]{
 		NodeID:           nodeID,
 		Data:             data,
@@ -63,13 +91,30 @@ func NewNode[T any](nodeID string, data T, position Position, width int16, heigh
 	}
 }
 
+// ParentId returns the NodeID of the parent, or an empty string for the root.
 func (n *Node[T]) ParentId() string {
-	if n.IsRoot() {
-		return "0"
+	if n.parent == nil {
+		return ""
 	}
 	return n.parent.NodeID
 }
 
+func (n *Node[T]) Parent() *Node[T] {
+	return n.parent
+}
+
+func (n *Node[T]) Next() *Node[T] {
+	return n.next
+}
+
+func (n *Node[T]) Previous() *Node[T] {
+	return n.previous
+}
+
+func (n *Node[T]) FirstChild() *Node[T] {
+	return n.children
+}
+
 func (n *Node[T]) IsRoot() bool {
 	return n.parent == nil && n.previous == nil && n.next == nil
 }
@@ -106,216 +151,315 @@ func (n *Node[T]) AddChild(child *Node[T]) *Node[T] {
 	return child
 }
 
-func (n *Node[T]) GetRoot() (*Node[T], int) {
-	depth := 1
-	current := n
+// IsAncestorOf reports whether n is node itself or one of its ancestors.
+func (n *Node[T]) IsAncestorOf(node *Node[T]) bool {
+	for node != nil {
+		if node == n {
+			return true
+		}
+		node = node.parent
+	}
+	return false
+}
 
-	for current.parent != nil {
-		depth++
-		current = current.parent
+func (n *Node[T]) unlink() {
+	if n.previous != nil {
+		n.previous.next = n.next
+	} else if n.parent != nil {
+		n.parent.children = n.next
 	}
 
-	return current, depth
+	if n.next != nil {
+		n.next.previous = n.previous
+	}
+
+	n.parent = nil
+	n.previous = nil
+	n.next = nil
 }
 
-func (n *Node[T]) traversePreOrder(flags TraverseFlags, traverseFunc TraverseFunc[T]) bool {
-	if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-		return true
+// checkInsertable makes sure node can be linked somewhere around n.
+func (n *Node[T]) checkInsertable(node *Node[T]) error {
+	if node == nil {
+		return ErrNilNode
 	}
 
-	child := n.children
-	for child != nil {
-		if child.traversePreOrder(flags, traverseFunc) {
-			return true
-		}
-		child = child.next
+	if !node.IsRoot() {
+		return ErrNodeAttached
 	}
 
-	if flags&TraverseNonLeaves == 0 && traverseFunc(n) {
-		return true
+	if node.IsAncestorOf(n) {
+		return ErrNodeIsAncestor
 	}
 
-	return false
+	return nil
 }
 
-func (n *Node[T]) traverseInOrder(flags TraverseFlags, traverseFunc TraverseFunc[T]) bool {
-	if n.children != nil {
+// Detach unlinks n, together with its subtree, from its parent and siblings.
+func (n *Node[T]) Detach() *Node[T] {
+	n.unlink()
+	return n
+}
 
-		func() bool {
-			child := n.children
-			current := child
-			child = current.next
+// Remove takes n out of the tree. When withSubtree is false the children of n
+// are kept in the tree, taking the place n had among its siblings.
+func (n *Node[T]) Remove(withSubtree bool) error {
+	if withSubtree || n.children == nil {
+		n.unlink()
+		return nil
+	}
 
-			if current.traverseInOrder(flags, traverseFunc) {
-				return true
-			}
+	if n.parent == nil {
+		if n.children.next != nil {
+			return ErrRootHasSiblings
+		}
+		n.children.unlink()
+		return nil
+	}
 
-			if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-				return true
-			}
+	for n.children != nil {
+		child := n.children
+		child.unlink()
+		n.linkBefore(child)
+	}
 
-			for child != nil {
-				current = child
-				child = current.next
-				if current.traverseInOrder(flags, traverseFunc) {
-					return true
-				}
-			}
+	n.unlink()
+	return nil
+}
 
-			return false
-		}()
+func (n *Node[T]) linkBefore(node *Node[T]) {
+	node.parent = n.parent
+	node.next = n
+	node.previous = n.previous
 
+	if n.previous != nil {
+		n.previous.next = node
+	} else {
+		n.parent.children = node
 	}
 
-	if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-		return true
+	n.previous = node
+}
+
+func (n *Node[T]) linkAfter(node *Node[T]) {
+	node.parent = n.parent
+	node.previous = n
+	node.next = n.next
+
+	if n.next != nil {
+		n.next.previous = node
 	}
 
-	return false
+	n.next = node
 }
 
-func (n *Node[T]) traversePostOrder(flags TraverseFlags, traverseFunc TraverseFunc[T]) bool {
-	if n.children != nil {
-		func() bool {
-			child := n.children
-			for child != nil {
-				current := child
-				child = current.next
-				if current.traversePostOrder(flags, traverseFunc) {
-					return true
-				}
-			}
+// InsertChild places a detached child at the given position among the children
+// of n. An index of -1 appends it after the last child.
+func (n *Node[T]) InsertChild(index int, child *Node[T]) error {
+	if err := n.checkInsertable(child); err != nil {
+		return err
+	}
 
-			if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-				return true
-			}
+	if index < -1 {
+		return ErrIndexOutOfRange
+	}
 
-			return false
-		}()
+	if index == -1 {
+		n.AddChild(child)
+		return nil
 	}
 
-	if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-		return true
+	sibling := n.children
+	for i := 0; i < index; i++ {
+		if sibling == nil {
+			return ErrIndexOutOfRange
+		}
+		sibling = sibling.next
 	}
 
-	return false
+	if sibling == nil {
+		n.AddChild(child)
+		return nil
+	}
+
+	sibling.linkBefore(child)
+	return nil
 }
 
-func (n *Node[T]) depthTraversePreOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+// MoveTo moves n, together with its subtree, under newParent at the given
+// position. An index of -1 appends it after the last child.
+func (n *Node[T]) MoveTo(newParent *Node[T], index int) error {
+	if newParent == nil {
+		return ErrNilNode
+	}
 
-	if n.children != nil {
-		func() bool {
+	if n.IsAncestorOf(newParent) {
+		return ErrNodeIsAncestor
+	}
 
-			if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-				return true
-			}
+	parent, previous, next := n.parent, n.previous, n.next
+	n.unlink()
+
+	if err := newParent.InsertChild(index, n); err != nil {
+		switch {
+		case previous != nil:
+			previous.linkAfter(n)
+		case next != nil:
+			next.linkBefore(n)
+		case parent != nil:
+			parent.AddChild(n)
+		}
+		return err
+	}
 
-			depth--
-			if depth == 0 {
-				return false
-			}
+	return nil
+}
 
-			child := n.children
+// InsertBefore places a detached node right before n, under the same parent.
+func (n *Node[T]) InsertBefore(node *Node[T]) error {
+	if n.parent == nil {
+		return ErrNodeWithoutParent
+	}
 
-			for child != nil {
-				current := child
-				child = current.next
+	if err := n.checkInsertable(node); err != nil {
+		return err
+	}
 
-				if current.depthTraversePreOrder(flags, depth, traverseFunc) {
-					return true
-				}
-			}
+	n.linkBefore(node)
+	return nil
+}
 
-			return false
-		}()
+// InsertAfter places a detached node right after n, under the same parent.
+func (n *Node[T]) InsertAfter(node *Node[T]) error {
+	if n.parent == nil {
+		return ErrNodeWithoutParent
 	}
 
-	if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-		return true
+	if err := n.checkInsertable(node); err != nil {
+		return err
 	}
 
-	return false
+	n.linkAfter(node)
+	return nil
 }
 
-func (n *Node[T]) depthTraverseInOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+// ReplaceWith puts a detached node in the place of n. The subtree of n leaves
+// the tree along with it.
+func (n *Node[T]) ReplaceWith(node *Node[T]) error {
+	if err := n.checkInsertable(node); err != nil {
+		return err
+	}
 
-	if n.children != nil {
+	if n.parent != nil {
+		n.linkBefore(node)
+	}
 
-		func() bool {
-			depth--
-			if depth > 0 {
+	n.unlink()
+	return nil
+}
 
-				child := n.children
-				current := child
-				child = current.next
+func (n *Node[T]) GetRoot() (*Node[T], int) {
+	depth := 1
+	current := n
 
-				if current.depthTraverseInOrder(flags, depth, traverseFunc) {
-					return true
-				}
+	for current.parent != nil {
+		depth++
+		current = current.parent
+	}
 
-				if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-					return true
-				}
+	return current, depth
+}
 
-				for child != nil {
-					current = child
-					child = current.next
-					if current.depthTraverseInOrder(flags, depth, traverseFunc) {
-						return true
-					}
-				}
+// traversePreOrder visits n before its children. traverseInOrder visits n
+// after its first child and before the others, and traversePostOrder after
+// all of them. A negative depth means no limit.
+func (n *Node[T]) traversePreOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+	if n.visit(flags, traverseFunc) {
+		return true
+	}
 
-			}
+	if depth == 1 {
+		return false
+	}
 
-			if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-				return true
-			}
+	for child := n.children; child != nil; child = child.next {
+		if child.traversePreOrder(flags, depth-1, traverseFunc) {
+			return true
+		}
+	}
 
-			return false
-		}()
+	return false
+}
 
-		return false
+func (n *Node[T]) traverseInOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+	if n.children == nil || depth == 1 {
+		return n.visit(flags, traverseFunc)
 	}
 
-	if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
+	if n.children.traverseInOrder(flags, depth-1, traverseFunc) {
 		return true
 	}
 
+	if n.visit(flags, traverseFunc) {
+		return true
+	}
+
+	for child := n.children.next; child != nil; child = child.next {
+		if child.traverseInOrder(flags, depth-1, traverseFunc) {
+			return true
+		}
+	}
+
 	return false
 }
 
-func (n *Node[T]) depthTraversePostOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+func (n *Node[T]) traversePostOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+	if depth != 1 {
+		for child := n.children; child != nil; child = child.next {
+			if child.traversePostOrder(flags, depth-1, traverseFunc) {
+				return true
+			}
+		}
+	}
+
+	return n.visit(flags, traverseFunc)
+}
 
+func (n *Node[T]) visit(flags TraverseFlags, traverseFunc TraverseFunc[T]) bool {
 	if n.children != nil {
-		func() bool {
-			depth--
-
-			if depth > 0 {
-				child := n.children
-
-				for child != nil {
-					current := child
-					child = current.next
-					if current.depthTraversePostOrder(flags, depth, traverseFunc) {
-						return true
-					}
-				}
-			}
+		return flags&TraverseNonLeaves != 0 && traverseFunc(n)
+	}
+	return flags&TraverseLeaves != 0 && traverseFunc(n)
+}
+
+// traverseLevelOrder walks the tree breadth-first, visiting every node of a level
+// before moving to the next one. A negative depth means no limit.
+func (n *Node[T]) traverseLevelOrder(flags TraverseFlags, depth int, traverseFunc TraverseFunc[T]) bool {
+	level := []*Node[T]{n}
 
-			if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
+	for len(level) > 0 && depth != 0 {
+		var next []*Node[T]
+
+		for _, current := range level {
+			if current.visit(flags, traverseFunc) {
 				return true
 			}
-			return false
-		}()
-	}
 
-	if flags&TraverseNonLeaves != 0 && traverseFunc(n) {
-		return true
+			for child := current.children; child != nil; child = child.next {
+				next = append(next, child)
+			}
+		}
+
+		depth--
+		level = next
 	}
 
 	return false
 }
 
+// Traverse calls trTraverseFunc on the nodes of the tree under n picked by
+// flags, in the given order, until it returns true. A depth of 1 visits n
+// alone and -1 the whole tree.
 func (n *Node[T]) Traverse(order TraverseType, flags TraverseFlags, depth int, trTraverseFunc TraverseFunc[T]) {
 
 	if n == nil || trTraverseFunc == nil || order > TraverseLevelOrder || flags > TraverseMask || (depth < -1 || depth == 0) {
@@ -323,37 +467,14 @@ func (n *Node[T]) Traverse(order TraverseType, flags TraverseFlags, depth int, t
 	}
 
 	switch order {
-
-	default:
-		fallthrough
-
-	case TraversePreOrder:
-		func() {
-			if depth < 0 {
-				n.traversePreOrder(flags, trTraverseFunc)
-				return
-			}
-			n.depthTraversePreOrder(flags, depth, trTraverseFunc)
-		}()
-
 	case TraverseInOrder:
-		func() {
-			if depth < 0 {
-				n.traverseInOrder(flags, trTraverseFunc)
-				return
-			}
-			n.depthTraverseInOrder(flags, depth, trTraverseFunc)
-		}()
-
+		n.traverseInOrder(flags, depth, trTraverseFunc)
 	case TraversePostOrder:
-		func() {
-			if depth < 0 {
-				n.traversePostOrder(flags, trTraverseFunc)
-				return
-			}
-			n.depthTraversePostOrder(flags, depth, trTraverseFunc)
-		}()
-
+		n.traversePostOrder(flags, depth, trTraverseFunc)
+	case TraverseLevelOrder:
+		n.traverseLevelOrder(flags, depth, trTraverseFunc)
+	default:
+		n.traversePreOrder(flags, depth, trTraverseFunc)
 	}
 }
 
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTree builds a(b(d, e), c) and returns its nodes by id.
+func newTree() map[string]*Node[string] {
+	nodes := map[string]*Node[string]{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		nodes[id] = &Node[string]{NodeID: id}
+	}
+
+	nodes["a"].AddChild(nodes["b"])
+	nodes["a"].AddChild(nodes["c"])
+	nodes["b"].AddChild(nodes["d"])
+	nodes["b"].AddChild(nodes["e"])
+
+	return nodes
+}
+
+func visited(root *Node[string], order TraverseType, flags TraverseFlags, depth int) string {
+	var ids []string
+	root.Traverse(order, flags, depth, func(n *Node[string]) bool {
+		ids = append(ids, n.NodeID)
+		return false
+	})
+	return strings.Join(ids, " ")
+}
+
+func TestTraverse(t *testing.T) {
+	tests := []struct {
+		name  string
+		order TraverseType
+		flags TraverseFlags
+		depth int
+		want  string
+	}{
+		{"pre order", TraversePreOrder, TraverseAll, -1, "a b d e c"},
+		{"in order", TraverseInOrder, TraverseAll, -1, "d b e a c"},
+		{"post order", TraversePostOrder, TraverseAll, -1, "d e b c a"},
+		{"level order", TraverseLevelOrder, TraverseAll, -1, "a b c d e"},
+		{"leaves", TraversePreOrder, TraverseLeaves, -1, "d e c"},
+		{"non leaves", TraverseLevelOrder, TraverseNonLeaves, -1, "a b"},
+		{"two levels", TraversePreOrder, TraverseAll, 2, "a b c"},
+		{"two levels post order", TraversePostOrder, TraverseAll, 2, "b c a"},
+		{"two levels in order", TraverseInOrder, TraverseAll, 2, "b a c"},
+		{"one level", TraverseLevelOrder, TraverseAll, 1, "a"},
+		{"zero depth", TraversePreOrder, TraverseAll, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visited(newTree()["a"], tt.order, tt.flags, tt.depth); got != tt.want {
+				t.Errorf("Traverse() visited %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraverseStops(t *testing.T) {
+	var ids []string
+	newTree()["a"].Traverse(TraversePreOrder, TraverseAll, -1, func(n *Node[string]) bool {
+		ids = append(ids, n.NodeID)
+		return n.NodeID == "d"
+	})
+
+	if got := strings.Join(ids, " "); got != "a b d" {
+		t.Errorf("Traverse() visited %q, want it to stop after d", got)
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	nodes := newTree()
+
+	if err := nodes["d"].MoveTo(nodes["c"], -1); err != nil {
+		t.Fatalf("MoveTo() error = %v", err)
+	}
+
+	if got := visited(nodes["a"], TraversePreOrder, TraverseAll, -1); got != "a b e c d" {
+		t.Errorf("tree after moving d under c = %q", got)
+	}
+
+	if err := nodes["e"].MoveTo(nodes["a"], 0); err != nil {
+		t.Fatalf("MoveTo() error = %v", err)
+	}
+
+	if got := visited(nodes["a"], TraversePreOrder, TraverseAll, -1); got != "a e b c d" {
+		t.Errorf("tree after moving e first under a = %q", got)
+	}
+}
+
+func TestMoveToFailureKeepsTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		node   string
+		parent string
+		index  int
+		want   error
+	}{
+		{"under itself", "b", "b", -1, ErrNodeIsAncestor},
+		{"under a descendant", "b", "d", -1, ErrNodeIsAncestor},
+		{"past the last child", "e", "c", 3, ErrIndexOutOfRange},
+		{"nil parent", "e", "", -1, ErrNilNode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := newTree()
+
+			err := nodes[tt.node].MoveTo(nodes[tt.parent], tt.index)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("MoveTo() error = %v, want %v", err, tt.want)
+			}
+
+			if got := visited(nodes["a"], TraversePreOrder, TraverseAll, -1); got != "a b d e c" {
+				t.Errorf("tree after a failed move = %q, want it unchanged", got)
+			}
+		})
+	}
+}
+
+func TestRemoveKeepsChildren(t *testing.T) {
+	nodes := newTree()
+
+	if err := nodes["b"].Remove(false); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	if got := visited(nodes["a"], TraversePreOrder, TraverseAll, -1); got != "a d e c" {
+		t.Errorf("tree after removing b = %q, want d and e in its place", got)
+	}
+
+	if err := nodes["a"].Remove(false); !errors.Is(err, ErrRootHasSiblings) {
+		t.Errorf("Remove() of a root with several children error = %v, want %v", err, ErrRootHasSiblings)
+	}
+}
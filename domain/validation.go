@@ -0,0 +1,348 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ViolationRule string
+
+const (
+	RuleEmptyNodeID        ViolationRule = "empty_node_id"
+	RuleDuplicateNodeID    ViolationRule = "duplicate_node_id"
+	RuleEmptyEdgeID        ViolationRule = "empty_edge_id"
+	RuleDuplicateEdgeID    ViolationRule = "duplicate_edge_id"
+	RuleEdgeSourceNotFound ViolationRule = "edge_source_not_found"
+	RuleEdgeTargetNotFound ViolationRule = "edge_target_not_found"
+	RuleMissingRoot        ViolationRule = "missing_root"
+	RuleMultipleRoots      ViolationRule = "multiple_roots"
+	RuleOrphanNode         ViolationRule = "orphan_node"
+	RuleUnreachableNode    ViolationRule = "unreachable_node"
+	RuleCycle              ViolationRule = "cycle"
+	RuleParentNodeNotFound ViolationRule = "parent_node_not_found"
+	RuleNullNode           ViolationRule = "null_node"
+	RuleNullEdge           ViolationRule = "null_edge"
+)
+
+// Violation is a single problem found in a flowchart, pointing to the node or
+// edge that causes it when there is one.
+type Violation struct {
+	Rule    ViolationRule
+	NodeID  string
+	EdgeID  string
+	Message string
+}
+
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return fmt.Sprintf("invalid flowchart: %s", strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) add(rule ViolationRule, nodeID string, edgeID string, format string, args ...any) {
+	e.Violations = append(e.Violations, Violation{
+		Rule:    rule,
+		NodeID:  nodeID,
+		EdgeID:  edgeID,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// ValidateEntries reports the null entries of the node and edge lists of a
+// flowchart, which have to be ruled out before the lists are converted and
+// checked by Validate. It returns nil when there are none.
+func ValidateEntries[N any, E any](nodes []*N, edges []*E) *ValidationError {
+	report := &ValidationError{}
+
+	for i, node := range nodes {
+		if node == nil {
+			report.add(RuleNullNode, "", "", "nodes[%d] is null", i)
+		}
+	}
+
+	for i, edge := range edges {
+		if edge == nil {
+			report.add(RuleNullEdge, "", "", "edges[%d] is null", i)
+		}
+	}
+
+	if len(report.Violations) == 0 {
+		return nil
+	}
+
+	return report
+}
+
+// Validate checks the structure of a flowchart before it is turned into a tree
+// and collects every violation found instead of stopping at the first one.
+//
+// Merges and loops are allowed, as long as every node can be reached from a
+// single root. Loops that cannot be entered from the root are reported as
+// cycles. Group nodes, which other nodes are drawn inside, may have no edges.
+// It returns nil when the flowchart is valid.
+func Validate[T any](nodes []*Node[T], edges []*Edge) *ValidationError {
+	report := &ValidationError{}
+
+	known := make(map[string]bool, len(nodes))
+	ids := make([]string, 0, len(nodes))
+
+	for _, node := range nodes {
+		switch {
+		case node.NodeID == "":
+			report.add(RuleEmptyNodeID, "", "", "node without id")
+		case known[node.NodeID]:
+			report.add(RuleDuplicateNodeID, node.NodeID, "", "node %q is declared more than once", node.NodeID)
+		default:
+			known[node.NodeID] = true
+			ids = append(ids, node.NodeID)
+		}
+	}
+
+	edgeIDs := make(map[string]bool, len(edges))
+	inDegree := make(map[string]int, len(ids))
+	degree := make(map[string]int, len(ids))
+	outgoing := make(map[string][]string, len(ids))
+
+	for _, edge := range edges {
+		switch {
+		case edge.ID == "":
+			report.add(RuleEmptyEdgeID, "", "", "edge from %q to %q has no id", edge.Source, edge.Target)
+		case edgeIDs[edge.ID]:
+			report.add(RuleDuplicateEdgeID, "", edge.ID, "edge %q is declared more than once", edge.ID)
+		}
+		edgeIDs[edge.ID] = true
+
+		valid := true
+		if !known[edge.Source] {
+			report.add(RuleEdgeSourceNotFound, edge.Source, edge.ID, "edge %q starts at unknown node %q", edge.ID, edge.Source)
+			valid = false
+		}
+		if !known[edge.Target] {
+			report.add(RuleEdgeTargetNotFound, edge.Target, edge.ID, "edge %q ends at unknown node %q", edge.ID, edge.Target)
+			valid = false
+		}
+		if !valid {
+			continue
+		}
+
+		inDegree[edge.Target]++
+		degree[edge.Source]++
+		degree[edge.Target]++
+		outgoing[edge.Source] = append(outgoing[edge.Source], edge.Target)
+	}
+
+	groups := groupNodes(nodes)
+
+	for _, node := range nodes {
+		if node.ParentNode != "" && !known[node.ParentNode] {
+			report.add(RuleParentNodeNotFound, node.NodeID, "", "node %q is drawn inside unknown node %q", node.NodeID, node.ParentNode)
+		}
+	}
+
+	for _, id := range ids {
+		if len(ids) > 1 && degree[id] == 0 && !groups[id] {
+			report.add(RuleOrphanNode, id, "", "node %q is not connected to any other node", id)
+		}
+	}
+
+	roots := inferRoots(nodes, ids, inDegree, degree)
+
+	switch {
+	case len(roots) == 0 && len(ids) > 0:
+		report.add(RuleMissingRoot, "", "", "there is no root node: every node has an incoming edge and none is of type %q", InputNodeType)
+	case len(ids) == 0:
+		report.add(RuleMissingRoot, "", "", "flowchart has no nodes")
+	case len(roots) > 1:
+		for _, root := range roots {
+			report.add(RuleMultipleRoots, root, "", "node %q is one of %d root nodes", root, len(roots))
+		}
+	}
+
+	if len(roots) > 0 {
+		validateReachability(report, ids, roots, outgoing, degree)
+	}
+
+	if len(report.Violations) == 0 {
+		return nil
+	}
+
+	return report
+}
+
+// groupNodes returns the ids of the nodes other nodes are drawn inside.
+func groupNodes[T any](nodes []*Node[T]) map[string]bool {
+	groups := map[string]bool{}
+	for _, node := range nodes {
+		if node.ParentNode != "" {
+			groups[node.ParentNode] = true
+		}
+	}
+	return groups
+}
+
+// inferRoots returns the connected nodes without incoming edges. When every node
+// has one, which happens when the flow loops back to its start, the nodes of
+// type input are taken as roots instead.
+func inferRoots[T any](nodes []*Node[T], ids []string, inDegree map[string]int, degree map[string]int) []string {
+	var roots []string
+	for _, id := range ids {
+		if inDegree[id] == 0 && (len(ids) == 1 || degree[id] > 0) {
+			roots = append(roots, id)
+		}
+	}
+
+	if len(roots) > 0 {
+		return roots
+	}
+
+	seen := make(map[string]bool, len(ids))
+	for _, node := range nodes {
+		if node.Type == InputNodeType && !seen[node.NodeID] {
+			seen[node.NodeID] = true
+			roots = append(roots, node.NodeID)
+		}
+	}
+
+	return roots
+}
+
+// FindRoot infers the root of a flowchart from its graph: the only node
+// without incoming edges or, failing that, the only node of type input. It
+// returns nil when the root is missing or ambiguous.
+func FindRoot[T any](nodes []*Node[T], edges []*Edge) *Node[T] {
+	byID := make(map[string]*Node[T], len(nodes))
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if _, ok := byID[node.NodeID]; !ok {
+			byID[node.NodeID] = node
+			ids = append(ids, node.NodeID)
+		}
+	}
+
+	inDegree := make(map[string]int, len(ids))
+	degree := make(map[string]int, len(ids))
+	for _, edge := range edges {
+		if byID[edge.Source] == nil || byID[edge.Target] == nil {
+			continue
+		}
+		inDegree[edge.Target]++
+		degree[edge.Source]++
+		degree[edge.Target]++
+	}
+
+	roots := inferRoots(nodes, ids, inDegree, degree)
+	if len(roots) != 1 {
+		return nil
+	}
+
+	return byID[roots[0]]
+}
+
+// validateReachability reports the connected nodes that cannot be reached from
+// any root, telling apart the ones caught in a closed loop.
+func validateReachability(report *ValidationError, ids []string, roots []string, outgoing map[string][]string, degree map[string]int) {
+	reachable := make(map[string]bool, len(ids))
+	queue := append([]string(nil), roots...)
+	for _, root := range roots {
+		reachable[root] = true
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range outgoing[current] {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	cyclic := cyclicNodes(ids, outgoing)
+
+	for _, id := range ids {
+		if reachable[id] || degree[id] == 0 {
+			continue
+		}
+
+		if cyclic[id] {
+			report.add(RuleCycle, id, "", "node %q is part of a loop that cannot be reached from the root", id)
+			continue
+		}
+
+		report.add(RuleUnreachableNode, id, "", "node %q cannot be reached from the root", id)
+	}
+}
+
+// cyclicNodes finds the nodes that belong to a loop, using Tarjan's strongly
+// connected components algorithm.
+func cyclicNodes(ids []string, outgoing map[string][]string) map[string]bool {
+	index := make(map[string]int, len(ids))
+	lowLink := make(map[string]int, len(ids))
+	onStack := make(map[string]bool, len(ids))
+	cyclic := make(map[string]bool)
+	var stack []string
+	counter := 0
+
+	var connect func(id string)
+	connect = func(id string) {
+		counter++
+		index[id] = counter
+		lowLink[id] = counter
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range outgoing[id] {
+			if next == id {
+				cyclic[id] = true
+			}
+
+			if _, visited := index[next]; !visited {
+				connect(next)
+				if lowLink[next] < lowLink[id] {
+					lowLink[id] = lowLink[next]
+				}
+			} else if onStack[next] {
+				if index[next] < lowLink[id] {
+					lowLink[id] = index[next]
+				}
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == id {
+				break
+			}
+		}
+
+		if len(component) > 1 {
+			for _, member := range component {
+				cyclic[member] = true
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if _, visited := index[id]; !visited {
+			connect(id)
+		}
+	}
+
+	return cyclic
+}
@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// graph builds the nodes and edges of a flowchart from edges written as
+// "source>target". Nodes are declared by the edges unless listed in ids.
+func graph(ids []string, links ...string) ([]*Node[string], []*Edge) {
+	var nodes []*Node[string]
+	declared := map[string]bool{}
+
+	declare := func(id string) {
+		if !declared[id] {
+			declared[id] = true
+			nodes = append(nodes, &Node[string]{NodeID: id})
+		}
+	}
+
+	for _, id := range ids {
+		declare(id)
+	}
+
+	var edges []*Edge
+	for _, link := range links {
+		source, target, _ := strings.Cut(link, ">")
+		declare(source)
+		declare(target)
+		edges = append(edges, NewEdge(EdgeID(source, target), source, target))
+	}
+
+	return nodes, edges
+}
+
+func byID(nodes []*Node[string], id string) *Node[string] {
+	for _, node := range nodes {
+		if node.NodeID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+func rules(err *ValidationError) string {
+	if err == nil {
+		return "[]"
+	}
+
+	var found []string
+	for _, v := range err.Violations {
+		found = append(found, string(v.Rule))
+	}
+	sort.Strings(found)
+	return fmt.Sprint(found)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() ([]*Node[string], []*Edge)
+		want  string
+	}{
+		{
+			name:  "single node",
+			build: func() ([]*Node[string], []*Edge) { return graph([]string{"a"}) },
+			want:  "[]",
+		},
+		{
+			name:  "merge and loop",
+			build: func() ([]*Node[string], []*Edge) { return graph(nil, "a>b", "a>c", "b>d", "c>d", "d>b") },
+			want:  "[]",
+		},
+		{
+			name: "loop back to an input root",
+			build: func() ([]*Node[string], []*Edge) {
+				nodes, edges := graph(nil, "a>b", "b>c", "c>a")
+				nodes[0].Type = InputNodeType
+				return nodes, edges
+			},
+			want: "[]",
+		},
+		{
+			name:  "loop without an input node",
+			build: func() ([]*Node[string], []*Edge) { return graph(nil, "a>b", "b>a") },
+			want:  "[missing_root]",
+		},
+		{
+			name:  "two roots",
+			build: func() ([]*Node[string], []*Edge) { return graph(nil, "a>c", "b>c") },
+			want:  "[multiple_roots multiple_roots]",
+		},
+		{
+			name:  "closed loop beside the flow",
+			build: func() ([]*Node[string], []*Edge) { return graph(nil, "a>b", "c>d", "d>c") },
+			want:  "[cycle cycle]",
+		},
+		{
+			name:  "node without edges",
+			build: func() ([]*Node[string], []*Edge) { return graph([]string{"x"}, "a>b") },
+			want:  "[orphan_node]",
+		},
+		{
+			name: "group without edges",
+			build: func() ([]*Node[string], []*Edge) {
+				nodes, edges := graph([]string{"g"}, "a>b")
+				byID(nodes, "b").ParentNode = "g"
+				return nodes, edges
+			},
+			want: "[]",
+		},
+		{
+			name: "unknown group",
+			build: func() ([]*Node[string], []*Edge) {
+				nodes, edges := graph(nil, "a>b")
+				byID(nodes, "b").ParentNode = "g"
+				return nodes, edges
+			},
+			want: "[parent_node_not_found]",
+		},
+		{
+			name: "edge to an unknown node",
+			build: func() ([]*Node[string], []*Edge) {
+				nodes, edges := graph(nil, "a>b")
+				return nodes, append(edges, NewEdge("b-z", "b", "z"))
+			},
+			want: "[edge_target_not_found]",
+		},
+		{
+			name: "duplicate ids",
+			build: func() ([]*Node[string], []*Edge) {
+				nodes, edges := graph(nil, "a>b")
+				nodes = append(nodes, &Node[string]{NodeID: "b"})
+				return nodes, append(edges, NewEdge(edges[0].ID, "a", "b"))
+			},
+			want: "[duplicate_edge_id duplicate_node_id]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, edges := tt.build()
+
+			if got := rules(Validate(nodes, edges)); got != tt.want {
+				t.Errorf("Validate() rules = %s, want %s (%v)", got, tt.want, Validate(nodes, edges))
+			}
+		})
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	nodes, edges := graph(nil, "a>b", "b>c", "c>b")
+	if root := FindRoot(nodes, edges); root == nil || root.NodeID != "a" {
+		t.Errorf("FindRoot() = %v, want a", root)
+	}
+
+	nodes, edges = graph(nil, "a>b", "b>c", "c>a")
+	if root := FindRoot(nodes, edges); root != nil {
+		t.Errorf("FindRoot() of a loop without input node = %v, want nil", root)
+	}
+
+	byID(nodes, "b").Type = InputNodeType
+	if root := FindRoot(nodes, edges); root == nil || root.NodeID != "b" {
+		t.Errorf("FindRoot() of a loop = %v, want the input node b", root)
+	}
+}
+
+func TestValidateEntries(t *testing.T) {
+	nodes, edges := graph(nil, "a>b")
+
+	if err := ValidateEntries(nodes, edges); err != nil {
+		t.Errorf("ValidateEntries() = %v, want nil", err)
+	}
+
+	nodes = append(nodes, nil)
+	edges = append([]*Edge{nil}, edges...)
+
+	err := ValidateEntries(nodes, edges)
+	if got := rules(err); got != "[null_edge null_node]" {
+		t.Fatalf("ValidateEntries() rules = %s, want a null node and a null edge", got)
+	}
+
+	if got := err.Error(); got != "invalid flowchart: nodes[2] is null; edges[0] is null" {
+		t.Errorf("ValidateEntries() = %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"flowChart/domain"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	bpmnModelNamespace = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+	bpmnDiNamespace    = "http://www.omg.org/spec/BPMN/20100524/DI"
+	dcNamespace        = "http://www.omg.org/spec/DD/20100524/DC"
+	diNamespace        = "http://www.omg.org/spec/DD/20100524/DI"
+)
+
+// bpmnElements maps node types to BPMN flow elements. Unknown types are written
+// as tasks.
+var bpmnElements = map[string]string{
+	domain.InputNodeType: "startEvent",
+	"output":             "endEvent",
+	"default":            "task",
+	"decision":           "exclusiveGateway",
+}
+
+var bpmnUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// Bpmn writes the flowchart as a BPMN 2.0 process, with a diagram keeping the
+// position and size of every node. Group nodes only frame other nodes in the
+// editor, so they are left out along with their edges.
+func Bpmn[T any](flowChart *domain.FlowChart[T]) string {
+	ids := bpmnIDs(flowChart)
+	process := ids[""]
+
+	var included []*domain.Node[T]
+	for _, node := range nodes(flowChart) {
+		if node.Type != "group" {
+			included = append(included, node)
+		}
+	}
+
+	var edges []*domain.Edge
+	for _, edge := range flowChart.Edges {
+		if bpmnIncluded(included, edge.Source) && bpmnIncluded(included, edge.Target) {
+			edges = append(edges, edge)
+		}
+	}
+
+	builder := &strings.Builder{}
+
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(builder, `<bpmn:definitions xmlns:bpmn="%s" xmlns:bpmndi="%s" xmlns:dc="%s" xmlns:di="%s" id="Definitions_%s" targetNamespace="http://bpmn.io/schema/bpmn">`+"\n",
+		bpmnModelNamespace, bpmnDiNamespace, dcNamespace, diNamespace, process)
+	fmt.Fprintf(builder, `  <bpmn:process id="%s" name="%s" isExecutable="false">`+"\n", process, svgText(flowChart.Title))
+
+	for _, node := range included {
+		element, ok := bpmnElements[node.Type]
+		if !ok {
+			element = bpmnElements["default"]
+		}
+
+		fmt.Fprintf(builder, `    <bpmn:%s id="%s" name="%s" />`+"\n", element, ids[node.NodeID], svgText(Label(node)))
+	}
+
+	for _, edge := range edges {
+		fmt.Fprintf(builder, `    <bpmn:sequenceFlow id="%s" sourceRef="%s" targetRef="%s"`, ids["edge:"+edge.ID], ids[edge.Source], ids[edge.Target])
+
+		if edge.Label != "" {
+			fmt.Fprintf(builder, ` name="%s"`, svgText(edge.Label))
+		}
+
+		builder.WriteString(" />\n")
+	}
+
+	builder.WriteString("  </bpmn:process>\n")
+	fmt.Fprintf(builder, `  <bpmndi:BPMNDiagram id="BPMNDiagram_%s">`+"\n", process)
+	fmt.Fprintf(builder, `    <bpmndi:BPMNPlane id="BPMNPlane_%s" bpmnElement="%s">`+"\n", process, process)
+
+	boxes := make(map[string]svgBox, len(included))
+	for _, node := range included {
+		box := absoluteBox(node)
+		boxes[node.NodeID] = box
+
+		fmt.Fprintf(builder, `      <bpmndi:BPMNShape id="%s_di" bpmnElement="%s">`+"\n", ids[node.NodeID], ids[node.NodeID])
+		fmt.Fprintf(builder, `        <dc:Bounds x="%s" y="%s" width="%s" height="%s" />`+"\n",
+			dotNumber(box.X), dotNumber(box.Y), dotNumber(box.Width), dotNumber(box.Height))
+		builder.WriteString("      </bpmndi:BPMNShape>\n")
+	}
+
+	for _, edge := range edges {
+		source, target := boxes[edge.Source], boxes[edge.Target]
+		id := ids["edge:"+edge.ID]
+
+		fmt.Fprintf(builder, `      <bpmndi:BPMNEdge id="%s_di" bpmnElement="%s">`+"\n", id, id)
+		fmt.Fprintf(builder, `        <di:waypoint x="%s" y="%s" />`+"\n", dotNumber(source.centerX()), dotNumber(source.Y+source.Height))
+		fmt.Fprintf(builder, `        <di:waypoint x="%s" y="%s" />`+"\n", dotNumber(target.centerX()), dotNumber(target.Y))
+		builder.WriteString("      </bpmndi:BPMNEdge>\n")
+	}
+
+	builder.WriteString("    </bpmndi:BPMNPlane>\n")
+	builder.WriteString("  </bpmndi:BPMNDiagram>\n")
+	builder.WriteString("</bpmn:definitions>\n")
+
+	return builder.String()
+}
+
+func bpmnIncluded[T any](nodes []*domain.Node[T], id string) bool {
+	for _, node := range nodes {
+		if node.NodeID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// absoluteBox is the box of a node in flowchart coordinates. Nodes inside a
+// group are positioned relative to it in the editor.
+func absoluteBox[T any](node *domain.Node[T]) svgBox {
+	box := nodeBox(node)
+
+	if node.ParentNode != "" {
+		box.X, box.Y = node.PositionAbsolute.X, node.PositionAbsolute.Y
+	}
+
+	return box
+}
+
+// bpmnIDs gives the process, every node and every edge an id valid in XML and
+// unique across the document. Ids that are already valid are kept, so they
+// survive a round trip. The process is found under the empty id and edges
+// under their id prefixed with "edge:".
+func bpmnIDs[T any](flowChart *domain.FlowChart[T]) map[string]string {
+	ids := map[string]string{}
+	taken := map[string]bool{}
+
+	add := func(key string, id string, prefix string) {
+		id = bpmnUnsafe.ReplaceAllString(id, "_")
+
+		if id == "" || !(id[0] == '_' || (id[0] >= 'A' && id[0] <= 'Z') || (id[0] >= 'a' && id[0] <= 'z')) {
+			id = prefix + id
+		}
+
+		for candidate, i := id, 1; ; i++ {
+			if !taken[candidate] {
+				id = candidate
+				break
+			}
+			candidate = fmt.Sprintf("%s_%d", id, i)
+		}
+
+		taken[id] = true
+		ids[key] = id
+	}
+
+	for _, node := range nodes(flowChart) {
+		add(node.NodeID, node.NodeID, "Node_")
+	}
+
+	for _, edge := range flowChart.Edges {
+		add("edge:"+edge.ID, edge.ID, "Flow_")
+	}
+
+	add("", "Process_"+flowChart.Key, "Process_")
+
+	return ids
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"flowChart/domain"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pixelsPerInch converts React Flow sizes into the inches Graphviz expects for
+// node widths and heights. Positions are in points, taken one to one.
+const pixelsPerInch = 72
+
+// dotShapes maps node types to Graphviz attributes. Unknown types are drawn as
+// default nodes.
+var dotShapes = map[string]string{
+	domain.InputNodeType: `shape=box, style=rounded`,
+	"output":             `shape=circle`,
+	"default":            `shape=box`,
+	"decision":           `shape=diamond`,
+}
+
+type DotOptions struct {
+	// IgnorePositions leaves the layout to Graphviz instead of pinning every
+	// node where it was drawn in the editor.
+	IgnorePositions bool
+}
+
+// Dot writes the flowchart as a Graphviz digraph. With positions kept the
+// graph asks for the neato layout, which honours pinned pos attributes.
+func Dot[T any](flowChart *domain.FlowChart[T], options DotOptions) string {
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, "digraph %s {\n", dotQuote(flowChart.Key))
+	fmt.Fprintf(builder, "    label=%s;\n", dotQuote(flowChart.Title))
+
+	if options.IgnorePositions {
+		builder.WriteString("    rankdir=TB;\n")
+	} else {
+		builder.WriteString("    layout=neato;\n")
+	}
+
+	for _, node := range nodes(flowChart) {
+		shape, ok := dotShapes[node.Type]
+		if !ok {
+			shape = dotShapes["default"]
+		}
+
+		attributes := []string{"label=" + dotQuote(Label(node)), shape}
+
+		if node.Width > 0 && node.Height > 0 {
+			attributes = append(attributes,
+				"width="+dotNumber(node.Width/pixelsPerInch),
+				"height="+dotNumber(node.Height/pixelsPerInch),
+				"fixedsize=true",
+			)
+		}
+
+		if !options.IgnorePositions {
+			// React Flow places the top left corner with y growing down, Graphviz
+			// places the centre with y growing up.
+			x := node.Position.X + node.Width/2
+			y := -(node.Position.Y + node.Height/2)
+			attributes = append(attributes, fmt.Sprintf(`pos="%s,%s!"`, dotNumber(x), dotNumber(y)))
+		}
+
+		fmt.Fprintf(builder, "    %s [%s];\n", dotQuote(node.NodeID), strings.Join(attributes, ", "))
+	}
+
+	for _, edge := range flowChart.Edges {
+		if edge.Label != "" {
+			fmt.Fprintf(builder, "    %s -> %s [label=%s];\n", dotQuote(edge.Source), dotQuote(edge.Target), dotQuote(edge.Label))
+			continue
+		}
+
+		fmt.Fprintf(builder, "    %s -> %s;\n", dotQuote(edge.Source), dotQuote(edge.Target))
+	}
+
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`).Replace(text) + `"`
+}
+
+func dotNumber(value float64) string {
+	if value == 0 {
+		// drops the sign of -0
+		value = 0
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
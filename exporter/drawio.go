@@ -0,0 +1,109 @@
+package exporter
+
+import (
+	"flowChart/domain"
+	"fmt"
+	"strings"
+)
+
+// drawioStyles maps node types to draw.io cell styles. Unknown types are drawn
+// as default nodes.
+var drawioStyles = map[string]string{
+	domain.InputNodeType: "rounded=1;arcSize=50;whiteSpace=wrap;",
+	"output":             "ellipse;whiteSpace=wrap;",
+	"default":            "rounded=0;whiteSpace=wrap;",
+	"decision":           "rhombus;whiteSpace=wrap;",
+	"group":              "rounded=0;whiteSpace=wrap;container=1;verticalAlign=top;",
+}
+
+const drawioEdgeStyle = "edgeStyle=orthogonalEdgeStyle;rounded=0;"
+
+// Drawio writes the flowchart as an uncompressed draw.io file with a single
+// page. Nodes keep their position and size, and nodes inside a group are
+// placed in the container drawn for it.
+func Drawio[T any](flowChart *domain.FlowChart[T]) string {
+	ids := drawioIDs(flowChart)
+	all := flowChart.DrawOrder()
+
+	builder := &strings.Builder{}
+
+	builder.WriteString(`<mxfile host="NodeFlow" type="device">` + "\n")
+	fmt.Fprintf(builder, `  <diagram id="%s" name="%s">`+"\n", svgText(flowChart.Key), svgText(flowChart.Title))
+	builder.WriteString(`    <mxGraphModel grid="1" gridSize="10" guides="1" tooltips="1" connect="1" arrows="1" fold="1" page="1" pageScale="1" math="0" shadow="0">` + "\n")
+	builder.WriteString("      <root>\n")
+	builder.WriteString(`        <mxCell id="0" />` + "\n")
+	builder.WriteString(`        <mxCell id="1" parent="0" />` + "\n")
+
+	for _, node := range all {
+		style, ok := drawioStyles[node.Type]
+		if !ok {
+			style = drawioStyles["default"]
+		}
+
+		parent := "1"
+		if id, ok := ids[node.ParentNode]; ok {
+			parent = id
+		}
+
+		box := nodeBox(node)
+
+		fmt.Fprintf(builder, `        <mxCell id="%s" value="%s" style="%s" vertex="1" parent="%s">`+"\n",
+			ids[node.NodeID], svgText(Label(node)), style, parent)
+		fmt.Fprintf(builder, `          <mxGeometry x="%s" y="%s" width="%s" height="%s" as="geometry" />`+"\n",
+			dotNumber(box.X), dotNumber(box.Y), dotNumber(box.Width), dotNumber(box.Height))
+		builder.WriteString("        </mxCell>\n")
+	}
+
+	for _, edge := range flowChart.Edges {
+		source, ok := ids[edge.Source]
+		target, found := ids[edge.Target]
+
+		if !ok || !found {
+			continue
+		}
+
+		fmt.Fprintf(builder, `        <mxCell id="%s" value="%s" style="%s" edge="1" parent="1" source="%s" target="%s">`+"\n",
+			ids["edge:"+edge.ID], svgText(edge.Label), drawioEdgeStyle, source, target)
+		builder.WriteString(`          <mxGeometry relative="1" as="geometry" />` + "\n")
+		builder.WriteString("        </mxCell>\n")
+	}
+
+	builder.WriteString("      </root>\n")
+	builder.WriteString("    </mxGraphModel>\n")
+	builder.WriteString("  </diagram>\n")
+	builder.WriteString("</mxfile>\n")
+
+	return builder.String()
+}
+
+// drawioIDs gives every node and edge a cell id unique across the page, escaped
+// for XML. The ids 0 and 1 belong to the root cell and the layer, so nodes and
+// edges using them are renamed. Edges are found under their id prefixed with
+// "edge:".
+func drawioIDs[T any](flowChart *domain.FlowChart[T]) map[string]string {
+	ids := map[string]string{}
+	taken := map[string]bool{"0": true, "1": true}
+
+	add := func(key string, id string, prefix string) {
+		candidate := id
+		for i := 1; taken[candidate]; i++ {
+			candidate = prefix + id
+			if i > 1 {
+				candidate = fmt.Sprintf("%s%s-%d", prefix, id, i)
+			}
+		}
+
+		taken[candidate] = true
+		ids[key] = svgText(candidate)
+	}
+
+	for _, node := range nodes(flowChart) {
+		add(node.NodeID, node.NodeID, "node-")
+	}
+
+	for _, edge := range flowChart.Edges {
+		add("edge:"+edge.ID, edge.ID, "edge-")
+	}
+
+	return ids
+}
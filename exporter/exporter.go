@@ -0,0 +1,116 @@
+// Package exporter turns a stored flowchart into the text formats other tools
+// draw it with.
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"flowChart/domain"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+const (
+	MermaidFormat = "mermaid"
+	DotFormat     = "dot"
+	SvgFormat     = "svg"
+	BpmnFormat    = "bpmn"
+	DrawioFormat  = "drawio"
+	YamlFormat    = "yaml"
+)
+
+type Options struct {
+	DotOptions
+}
+
+// Document is an exported flowchart with the media type it is served with.
+type Document struct {
+	ContentType string
+	Body        []byte
+}
+
+// Export writes the flowchart in the given format.
+func Export[T any](flowChart *domain.FlowChart[T], format string, options Options) (*Document, error) {
+	switch strings.ToLower(format) {
+	case MermaidFormat:
+		return &Document{ContentType: "text/plain; charset=utf-8", Body: []byte(Mermaid(flowChart))}, nil
+	case DotFormat:
+		return &Document{ContentType: "text/vnd.graphviz; charset=utf-8", Body: []byte(Dot(flowChart, options.DotOptions))}, nil
+	case SvgFormat:
+		return &Document{ContentType: "image/svg+xml", Body: []byte(Svg(flowChart))}, nil
+	case BpmnFormat:
+		return &Document{ContentType: "application/xml; charset=utf-8", Body: []byte(Bpmn(flowChart))}, nil
+	case DrawioFormat:
+		return &Document{ContentType: "application/vnd.jgraph.mxfile; charset=utf-8", Body: []byte(Drawio(flowChart))}, nil
+	case YamlFormat:
+		body, err := Yaml(flowChart)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &Document{ContentType: "application/yaml; charset=utf-8", Body: body}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+// Label is the text shown for a node: the label React Flow keeps in its data,
+// or the node id when there is none.
+func Label[T any](node *domain.Node[T]) string {
+	raw, err := json.Marshal(node.Data)
+
+	if err != nil {
+		return node.NodeID
+	}
+
+	if label := findLabel(raw); label != "" {
+		return label
+	}
+
+	return node.NodeID
+}
+
+// findLabel looks for a "label" in an object, or in the first object of a list
+// holding one, as blocks coming from Wagtail do.
+func findLabel(raw []byte) string {
+	var object map[string]json.RawMessage
+
+	if err := json.Unmarshal(raw, &object); err == nil {
+		var label string
+		if err := json.Unmarshal(object["label"], &label); err == nil {
+			return label
+		}
+		return ""
+	}
+
+	var list []json.RawMessage
+
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, item := range list {
+			if label := findLabel(item); label != "" {
+				return label
+			}
+		}
+	}
+
+	return ""
+}
+
+// nodes lists the nodes of the flowchart from the root down.
+func nodes[T any](flowChart *domain.FlowChart[T]) []*domain.Node[T] {
+	var result []*domain.Node[T]
+
+	if flowChart.Node == nil {
+		return result
+	}
+
+	flowChart.Node.Traverse(domain.TraversePreOrder, domain.TraverseAll, -1, func(n *domain.Node[T]) bool {
+		result = append(result, n)
+		return false
+	})
+
+	return result
+}
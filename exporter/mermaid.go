@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"flowChart/domain"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// mermaidShapes wraps a label in the brackets of the shape drawn for each node
+// type. Unknown types are drawn as default nodes.
+var mermaidShapes = map[string][2]string{
+	domain.InputNodeType: {`([`, `])`},
+	"output":             {`((`, `))`},
+	"default":            {`[`, `]`},
+	"decision":           {`{`, `}`},
+}
+
+// Mermaid writes the flowchart as a top-down Mermaid flowchart. Nodes drawn
+// inside a group node are written inside a subgraph named after the group, so
+// importing the flowchart back keeps them in it.
+func Mermaid[T any](flowChart *domain.FlowChart[T]) string {
+	builder := &strings.Builder{}
+	builder.WriteString("flowchart TD\n")
+
+	ids := mermaidIDs(flowChart)
+	all := nodes(flowChart)
+
+	children := map[string][]*domain.Node[T]{}
+	var top []*domain.Node[T]
+	for _, node := range all {
+		if _, ok := ids[node.ParentNode]; ok && node.ParentNode != node.NodeID {
+			children[node.ParentNode] = append(children[node.ParentNode], node)
+			continue
+		}
+		top = append(top, node)
+	}
+
+	written := map[string]bool{}
+
+	var write func(node *domain.Node[T], indent string)
+	write = func(node *domain.Node[T], indent string) {
+		if written[node.NodeID] {
+			return
+		}
+		written[node.NodeID] = true
+
+		if inside, ok := children[node.NodeID]; ok || node.Type == "group" {
+			fmt.Fprintf(builder, "%ssubgraph %s [\"%s\"]\n", indent, ids[node.NodeID], mermaidText(Label(node)))
+			for _, child := range inside {
+				write(child, indent+"    ")
+			}
+			fmt.Fprintf(builder, "%send\n", indent)
+			return
+		}
+
+		shape, ok := mermaidShapes[node.Type]
+		if !ok {
+			shape = mermaidShapes["default"]
+		}
+
+		fmt.Fprintf(builder, "%s%s%s\"%s\"%s\n", indent, ids[node.NodeID], shape[0], mermaidText(Label(node)), shape[1])
+	}
+
+	for _, node := range top {
+		write(node, "    ")
+	}
+
+	// Groups drawn inside each other in a loop have no way out to the top.
+	for _, node := range all {
+		write(node, "    ")
+	}
+
+	for _, edge := range flowChart.Edges {
+		source, target := ids[edge.Source], ids[edge.Target]
+
+		if source == "" || target == "" {
+			continue
+		}
+
+		if edge.Label != "" {
+			fmt.Fprintf(builder, "    %s -->|\"%s\"| %s\n", source, mermaidText(edge.Label), target)
+			continue
+		}
+
+		fmt.Fprintf(builder, "    %s --> %s\n", source, target)
+	}
+
+	return builder.String()
+}
+
+// mermaidIDs gives every node an id Mermaid accepts. React Flow ids may hold
+// any character, and "end" is a Mermaid keyword.
+func mermaidIDs[T any](flowChart *domain.FlowChart[T]) map[string]string {
+	ids := map[string]string{}
+	taken := map[string]bool{}
+
+	for _, node := range nodes(flowChart) {
+		id := mermaidUnsafe.ReplaceAllString(node.NodeID, "_")
+
+		if id == "" || strings.EqualFold(id, "end") {
+			id = "n_" + id
+		}
+
+		for candidate, i := id, 1; ; i++ {
+			if !taken[candidate] {
+				id = candidate
+				break
+			}
+			candidate = fmt.Sprintf("%s_%d", id, i)
+		}
+
+		taken[id] = true
+		ids[node.NodeID] = id
+	}
+
+	return ids
+}
+
+// mermaidText escapes text for a quoted Mermaid label.
+func mermaidText(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\r\n", "<br/>", "\n", "<br/>").Replace(text)
+}
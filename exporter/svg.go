@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"flowChart/domain"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// defaultNodeWidth and defaultNodeHeight are the sizes React Flow gives a
+	// default node, used when a node was saved before it was measured.
+	defaultNodeWidth  = 150
+	defaultNodeHeight = 40
+	svgPadding        = 20
+)
+
+type svgBox struct {
+	X, Y, Width, Height float64
+}
+
+func (b svgBox) centerX() float64 { return b.X + b.Width/2 }
+func (b svgBox) centerY() float64 { return b.Y + b.Height/2 }
+
+func nodeBox[T any](node *domain.Node[T]) svgBox {
+	box := svgBox{X: node.Position.X, Y: node.Position.Y, Width: node.Width, Height: node.Height}
+
+	if box.Width <= 0 {
+		box.Width = defaultNodeWidth
+	}
+
+	if box.Height <= 0 {
+		box.Height = defaultNodeHeight
+	}
+
+	return box
+}
+
+// Svg draws the flowchart the way the editor lays it out: every node at its
+// stored position and size, and every edge as a curve from the bottom of its
+// source to the top of its target.
+func Svg[T any](flowChart *domain.FlowChart[T]) string {
+	all := nodes(flowChart)
+	boxes := make(map[string]svgBox, len(all))
+
+	minX, minY, maxX, maxY := 0.0, 0.0, 0.0, 0.0
+	for i, node := range all {
+		box := nodeBox(node)
+		boxes[node.NodeID] = box
+
+		if i == 0 {
+			minX, minY, maxX, maxY = box.X, box.Y, box.X+box.Width, box.Y+box.Height
+			continue
+		}
+
+		minX, minY = math.Min(minX, box.X), math.Min(minY, box.Y)
+		maxX, maxY = math.Max(maxX, box.X+box.Width), math.Max(maxY, box.Y+box.Height)
+	}
+
+	minX, minY = minX-svgPadding, minY-svgPadding
+	width, height := maxX-minX+svgPadding, maxY-minY+svgPadding
+
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s" font-family="sans-serif" font-size="12">`+"\n",
+		dotNumber(width), dotNumber(height), dotNumber(minX), dotNumber(minY), dotNumber(width), dotNumber(height))
+	fmt.Fprintf(builder, "<title>%s</title>\n", svgText(flowChart.Title))
+	builder.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse">` +
+		`<path d="M 0 0 L 10 5 L 0 10 z" fill="#b1b1b7"/></marker></defs>` + "\n")
+	fmt.Fprintf(builder, `<rect x="%s" y="%s" width="%s" height="%s" fill="#ffffff"/>`+"\n", dotNumber(minX), dotNumber(minY), dotNumber(width), dotNumber(height))
+
+	for _, edge := range flowChart.Edges {
+		source, ok := boxes[edge.Source]
+		target, found := boxes[edge.Target]
+
+		if !ok || !found {
+			continue
+		}
+
+		writeSvgEdge(builder, edge, source, target)
+	}
+
+	for _, node := range all {
+		writeSvgNode(builder, node, boxes[node.NodeID])
+	}
+
+	builder.WriteString("</svg>\n")
+
+	return builder.String()
+}
+
+func writeSvgEdge(builder *strings.Builder, edge *domain.Edge, source svgBox, target svgBox) {
+	x1, y1 := source.centerX(), source.Y+source.Height
+	x2, y2 := target.centerX(), target.Y
+
+	// the same bezier React Flow draws for its default edges
+	offset := math.Max(math.Abs(y2-y1)/2, 25)
+
+	fmt.Fprintf(builder, `<path d="M %s %s C %s %s, %s %s, %s %s" fill="none" stroke="#b1b1b7" stroke-width="1.5" marker-end="url(#arrow)"/>`+"\n",
+		dotNumber(x1), dotNumber(y1), dotNumber(x1), dotNumber(y1+offset), dotNumber(x2), dotNumber(y2-offset), dotNumber(x2), dotNumber(y2))
+
+	if edge.Label != "" {
+		fmt.Fprintf(builder, `<text x="%s" y="%s" text-anchor="middle" dominant-baseline="middle" fill="#222222">%s</text>`+"\n",
+			dotNumber((x1+x2)/2), dotNumber((y1+y2)/2), svgText(edge.Label))
+	}
+}
+
+func writeSvgNode[T any](builder *strings.Builder, node *domain.Node[T], box svgBox) {
+	const style = `fill="#ffffff" stroke="#1a192b" stroke-width="1"`
+
+	switch node.Type {
+	case domain.InputNodeType:
+		fmt.Fprintf(builder, `<rect x="%s" y="%s" width="%s" height="%s" rx="%s" %s/>`+"\n",
+			dotNumber(box.X), dotNumber(box.Y), dotNumber(box.Width), dotNumber(box.Height), dotNumber(box.Height/2), style)
+	case "output":
+		fmt.Fprintf(builder, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s" %s/>`+"\n",
+			dotNumber(box.centerX()), dotNumber(box.centerY()), dotNumber(box.Width/2), dotNumber(box.Height/2), style)
+	case "decision":
+		fmt.Fprintf(builder, `<polygon points="%s,%s %s,%s %s,%s %s,%s" %s/>`+"\n",
+			dotNumber(box.centerX()), dotNumber(box.Y), dotNumber(box.X+box.Width), dotNumber(box.centerY()),
+			dotNumber(box.centerX()), dotNumber(box.Y+box.Height), dotNumber(box.X), dotNumber(box.centerY()), style)
+	default:
+		fmt.Fprintf(builder, `<rect x="%s" y="%s" width="%s" height="%s" rx="3" %s/>`+"\n",
+			dotNumber(box.X), dotNumber(box.Y), dotNumber(box.Width), dotNumber(box.Height), style)
+	}
+
+	fmt.Fprintf(builder, `<text x="%s" y="%s" text-anchor="middle" dominant-baseline="middle" fill="#222222">%s</text>`+"\n",
+		dotNumber(box.centerX()), dotNumber(box.centerY()), svgText(Label(node)))
+}
+
+func svgText(text string) string {
+	builder := &strings.Builder{}
+	xml.EscapeText(builder, []byte(text))
+	return builder.String()
+}
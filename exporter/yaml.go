@@ -0,0 +1,32 @@
+package exporter
+
+import (
+	"encoding/json"
+	"flowChart/domain"
+	"flowChart/transport"
+)
+
+// Yaml writes the flowchart as the YAML kept in version control, which can be
+// imported back unchanged.
+func Yaml[T any](flowChart *domain.FlowChart[T]) ([]byte, error) {
+	dto := transport.FromDomain(flowChart, yamlData[T])
+
+	return transport.EncodeYAML(dto)
+}
+
+// yamlData turns node data into plain values, so data kept as raw JSON is
+// written as the object it holds rather than as bytes.
+func yamlData[T any](data T) transport.UnstructuredDataDto {
+	raw, err := json.Marshal(data)
+
+	if err != nil {
+		return data
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return data
+	}
+
+	return value
+}
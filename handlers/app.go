@@ -6,11 +6,24 @@ import (
 )
 
 type Commands struct {
-	EditFlowChart command.HandlerFlowChartUnstructuredData
+	EditFlowChart    command.HandlerFlowChartUnstructuredData
+	ReplaceFlowChart command.HandlerReplaceFlowChartUnstructuredData
+	PatchFlowChart   command.HandlerPatchFlowChartUnstructuredData
+	JsonPatch        command.HandlerJsonPatchFlowChartUnstructuredData
+	DeleteFlowChart  command.HandlerDeleteFlowChartUnstructuredData
+	EditNode         command.HandlerNodeFlowChartUnstructuredData
+	EditEdge         command.HandlerEdgeFlowChartUnstructuredData
+	RestoreFlowChart command.HandlerRestoreFlowChartUnstructuredData
+	ImportFlowChart  command.HandlerImportFlowChartUnstructuredData
 }
 
 type Queries struct {
-	GetFlowChart queries.HandlerGetFlowChartUnstructuredData
+	GetFlowChart          queries.HandlerGetFlowChartUnstructuredData
+	ListFlowCharts        queries.HandlerListFlowChartsUnstructuredData
+	FlowChartExists       queries.HandlerFlowChartExistsUnstructuredData
+	ListFlowChartVersions queries.HandlerListFlowChartVersionsUnstructuredData
+	GetFlowChartVersion   queries.HandlerGetFlowChartVersionUnstructuredData
+	ExportFlowChart       queries.HandlerExportFlowChartUnstructuredData
 }
 
 type Application struct {
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"context"
+	"flowChart/adapters"
+)
+
+type DeleteFlowChartRepo interface {
+	DeleteFlowChart(ctx context.Context, key string) error
+}
+
+type DeleteHandlerFlowChart struct {
+	repo DeleteFlowChartRepo
+}
+
+func NewDeleteHandlerFlowChart(repo DeleteFlowChartRepo) *DeleteHandlerFlowChart {
+	return &DeleteHandlerFlowChart{
+		repo: repo,
+	}
+}
+
+func (h *DeleteHandlerFlowChart) Handler(ctx context.Context, key string) error {
+	return h.repo.DeleteFlowChart(ctx, key)
+}
+
+type HandlerDeleteFlowChartUnstructuredData struct {
+	*DeleteHandlerFlowChart
+}
+
+func NewHandlerDeleteFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerDeleteFlowChartUnstructuredData {
+	return HandlerDeleteFlowChartUnstructuredData{
+		NewDeleteHandlerFlowChart(agr),
+	}
+}
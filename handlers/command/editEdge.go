@@ -0,0 +1,177 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/transport"
+)
+
+// patchJson is the value a JSON field takes when it is sent in a patch: null
+// clears it.
+func patchJson(raw json.RawMessage) json.RawMessage {
+	if string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+// patchExtra merges the extra fields sent in a patch into the stored ones,
+// removing those sent as null.
+func patchExtra(stored domain.Extra, patch domain.Extra) domain.Extra {
+	if len(patch) == 0 {
+		return stored
+	}
+
+	merged := make(domain.Extra, len(stored)+len(patch))
+	for name, raw := range stored {
+		merged[name] = raw
+	}
+
+	for name, raw := range patch {
+		if string(raw) == "null" {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = raw
+	}
+
+	if len(merged) == 0 {
+		return nil
+	}
+
+	return merged
+}
+
+type EdgeHandlerFlowChart[T comparable] struct {
+	repo PatchFlowChartRepo[T]
+}
+
+func NewEdgeHandlerFlowChart[T comparable](repo PatchFlowChartRepo[T]) *EdgeHandlerFlowChart[T] {
+	return &EdgeHandlerFlowChart[T]{
+		repo: repo,
+	}
+}
+
+func (h *EdgeHandlerFlowChart[T]) Create(ctx context.Context, key string, id string, dto *transport.EdgeCreateDto) (*domain.Edge, error) {
+	flowChart, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := flowChart.FindEdgeByID(id); ok {
+		return nil, domain.ErrEdgeExists
+	}
+
+	dto.Id = id
+	edge := transport.EdgeToDomain(&dto.EdgeDto)
+	flowChart.AddEdge(edge)
+
+	if err := saveChange(ctx, h.repo, flowChart, dto.ChangeDto); err != nil {
+		return nil, err
+	}
+
+	return edge, nil
+}
+
+func (h *EdgeHandlerFlowChart[T]) Update(ctx context.Context, key string, id string, dto *transport.EdgePatchDto) (*domain.Edge, error) {
+	flowChart, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	edge, ok := flowChart.FindEdgeByID(id)
+
+	if !ok {
+		return nil, domain.ErrEdgeNotFound
+	}
+
+	if dto.Source != nil {
+		edge.Source = *dto.Source
+	}
+
+	if dto.Target != nil {
+		edge.Target = *dto.Target
+	}
+
+	if dto.SourceHandle.Set {
+		edge.SourceHandle = dto.SourceHandle.Value
+	}
+
+	if dto.TargetHandle.Set {
+		edge.TargetHandle = dto.TargetHandle.Value
+	}
+
+	if dto.Label != nil {
+		edge.Label = *dto.Label
+	}
+
+	if dto.Type != nil {
+		edge.Type = *dto.Type
+	}
+
+	if dto.Animated.Set {
+		edge.Animated = dto.Animated.Value
+	}
+
+	if dto.Style != nil {
+		edge.Style = patchJson(dto.Style)
+	}
+
+	if dto.Data != nil {
+		edge.Data = patchJson(dto.Data)
+	}
+
+	if dto.Hidden != nil {
+		edge.Hidden = *dto.Hidden
+	}
+
+	if dto.ZIndex.Set {
+		edge.ZIndex = dto.ZIndex.Value
+	}
+
+	if dto.MarkerStart != nil {
+		edge.MarkerStart = patchJson(dto.MarkerStart)
+	}
+
+	if dto.MarkerEnd != nil {
+		edge.MarkerEnd = patchJson(dto.MarkerEnd)
+	}
+
+	edge.Extra = patchExtra(edge.Extra, dto.Extra)
+
+	if err := saveChange(ctx, h.repo, flowChart, dto.ChangeDto); err != nil {
+		return nil, err
+	}
+
+	return edge, nil
+}
+
+func (h *EdgeHandlerFlowChart[T]) Delete(ctx context.Context, key string, id string, change transport.ChangeDto) error {
+	flowChart, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := flowChart.FindEdgeByID(id); !ok {
+		return domain.ErrEdgeNotFound
+	}
+
+	flowChart.RemoveEdges(func(edge *domain.Edge) bool { return edge.ID == id })
+
+	return saveChange(ctx, h.repo, flowChart, change)
+}
+
+type HandlerEdgeFlowChartUnstructuredData struct {
+	*EdgeHandlerFlowChart[domain.UnstructuredDataDomain]
+}
+
+func NewHandlerEdgeFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerEdgeFlowChartUnstructuredData {
+	return HandlerEdgeFlowChartUnstructuredData{
+		NewEdgeHandlerFlowChart[domain.UnstructuredDataDomain](agr),
+	}
+}
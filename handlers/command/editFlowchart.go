@@ -2,13 +2,29 @@ package command
 
 import (
 	"context"
+	"errors"
 	"flowChart/adapters"
 	"flowChart/domain"
 	"flowChart/transport"
+	"strings"
 
 	"fmt"
 )
 
+// ErrReservedKey is returned when creating a flowchart whose key would be read
+// as a request for the SVG rendering of another one.
+var ErrReservedKey = errors.New("flowchart keys ending in .svg are reserved")
+
+// checkNewKey makes sure the key of a new flowchart can be reached through
+// /flowchart/:key. Flowcharts stored before keys were checked keep theirs.
+func checkNewKey(key string) error {
+	if strings.HasSuffix(strings.ToLower(key), ".svg") {
+		return ErrReservedKey
+	}
+
+	return nil
+}
+
 type dtoToDomain[R comparable, D comparable] func(flowChart *transport.FlowChartDto[R], dataParse func(request R) D) (*domain.FlowChart[D], error)
 type dataParse[R comparable, D comparable] func(request R) D
 
@@ -33,23 +49,31 @@ func NewEditHandlerFlowChart[R comparable, D comparable](repo FlowCartRepo[D], p
 }
 
 func (h *EditHandlerFlowChart[R, D]) Handler(ctx context.Context, dto *transport.FlowChartDto[R]) error {
-	domain, err := h.dtoToDomain(dto, h.parseData)
+	flowChart, err := h.dtoToDomain(dto, h.parseData)
 
 	if err != nil {
 		return fmt.Errorf("error parsing dto to domain %w", err)
 	}
 
-	exists, err := h.repo.FlowChartExists(ctx, domain)
+	exists, err := h.repo.FlowChartExists(ctx, flowChart)
 
 	if err != nil {
 		return err
 	}
 
 	if exists {
-		return h.repo.UpdateFlowChart(ctx, domain)
+		return h.repo.UpdateFlowChart(ctx, flowChart)
+	}
+
+	if err := flowChart.CheckVersion(0, false); err != nil {
+		return err
+	}
+
+	if err := checkNewKey(flowChart.Key); err != nil {
+		return err
 	}
 
-	return h.repo.StoreFlowChart(ctx, domain)
+	return h.repo.StoreFlowChart(ctx, flowChart)
 
 }
 
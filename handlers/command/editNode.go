@@ -0,0 +1,204 @@
+package command
+
+import (
+	"context"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/transport"
+)
+
+// saveChange validates a flowchart changed in place and stores it. Unless the
+// change names the version it was based on, it must still be at the version it
+// was loaded with.
+func saveChange[T comparable](ctx context.Context, repo PatchFlowChartRepo[T], flowChart *domain.FlowChart[T], change transport.ChangeDto) error {
+	if err := flowChart.Relink(); err != nil {
+		return err
+	}
+
+	prepareChange(flowChart, change)
+
+	return repo.UpdateFlowChart(ctx, flowChart)
+}
+
+// prepareChange records who made the change and the version it was based on.
+func prepareChange[T comparable](flowChart *domain.FlowChart[T], change transport.ChangeDto) {
+	flowChart.ExpectedVersion = change.Version
+	if flowChart.ExpectedVersion == nil {
+		loaded := flowChart.Version
+		flowChart.ExpectedVersion = &loaded
+	}
+
+	flowChart.Author = change.Author
+	flowChart.Message = change.Message
+}
+
+type NodeFlowChartRepo[T comparable] interface {
+	PatchFlowChartRepo[T]
+	UpdateNode(ctx context.Context, flowChart *domain.FlowChart[T], node *domain.Node[T], newVersion bool) error
+}
+
+type NodeHandlerFlowChart[R comparable, D comparable] struct {
+	repo      NodeFlowChartRepo[D]
+	parseData dataParse[R, D]
+}
+
+func NewNodeHandlerFlowChart[R comparable, D comparable](repo NodeFlowChartRepo[D], parseData dataParse[R, D]) *NodeHandlerFlowChart[R, D] {
+	return &NodeHandlerFlowChart[R, D]{
+		repo:      repo,
+		parseData: parseData,
+	}
+}
+
+// Create adds a node under dto.Parent, connected to it by a new edge.
+func (h *NodeHandlerFlowChart[R, D]) Create(ctx context.Context, key string, id string, dto *transport.NodeCreateDto[R]) (*domain.Node[D], error) {
+	flowChart, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := flowChart.FindByID(id); ok {
+		return nil, domain.ErrNodeExists
+	}
+
+	dto.Id = id
+	node := transport.NodeToDomain(&dto.NodeDto, h.parseData)
+
+	if err := flowChart.AddNode(dto.Parent, node, -1); err != nil {
+		return nil, err
+	}
+
+	if err := saveChange[D](ctx, h.repo, flowChart, dto.ChangeDto); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// Update changes the fields present in dto. A change that leaves the node in
+// its place in the tree is written to its row alone, and one that touches only
+// the layout keeps the flowchart at its current version.
+func (h *NodeHandlerFlowChart[R, D]) Update(ctx context.Context, key string, id string, dto *transport.NodePatchDto[R]) (*domain.Node[D], error) {
+	flowChart, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := flowChart.FindByID(id)
+
+	if !ok {
+		return nil, domain.ErrNodeNotFound
+	}
+
+	if dto.Position != nil {
+		node.Position = domain.Position{X: dto.Position.X, Y: dto.Position.Y}
+	}
+
+	if dto.PositionAbsolute != nil {
+		node.PositionAbsolute = domain.Position{X: dto.PositionAbsolute.X, Y: dto.PositionAbsolute.Y}
+	}
+
+	if dto.Data != nil {
+		node.Data = h.parseData(*dto.Data)
+	}
+
+	if dto.Width != nil {
+		node.Width = *dto.Width
+	}
+
+	if dto.Height != nil {
+		node.Height = *dto.Height
+	}
+
+	if dto.Selected != nil {
+		node.Selected = *dto.Selected
+	}
+
+	if dto.Dragging != nil {
+		node.Dragging = *dto.Dragging
+	}
+
+	if dto.Type != nil {
+		node.Type = *dto.Type
+	}
+
+	if dto.ParentNode != nil {
+		node.ParentNode = *dto.ParentNode
+	}
+
+	if dto.ParentId != nil {
+		node.ParentNode = *dto.ParentId
+	}
+
+	if dto.Extent != nil {
+		node.Extent = patchJson(dto.Extent)
+	}
+
+	if dto.ZIndex.Set {
+		node.ZIndex = dto.ZIndex.Value
+	}
+
+	if dto.Hidden != nil {
+		node.Hidden = *dto.Hidden
+	}
+
+	if dto.Style != nil {
+		node.Style = patchJson(dto.Style)
+	}
+
+	node.Extra = patchExtra(node.Extra, dto.Extra)
+
+	if dto.Parent != nil {
+		if err := flowChart.MoveNode(id, *dto.Parent, -1); err != nil {
+			return nil, err
+		}
+	}
+
+	if dto.Parent != nil || dto.ParentNode != nil || dto.ParentId != nil || dto.Type != nil {
+		if err := saveChange[D](ctx, h.repo, flowChart, dto.ChangeDto); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+
+	layoutOnly := dto.Data == nil && dto.Extent == nil && !dto.ZIndex.Set && dto.Hidden == nil && dto.Style == nil && len(dto.Extra) == 0
+
+	prepareChange(flowChart, dto.ChangeDto)
+
+	if err := h.repo.UpdateNode(ctx, flowChart, node, !layoutOnly); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// Delete removes a node and its edges. Unless withSubtree is set, its children
+// are connected to its parent instead.
+func (h *NodeHandlerFlowChart[R, D]) Delete(ctx context.Context, key string, id string, withSubtree bool, change transport.ChangeDto) error {
+	flowChart, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := flowChart.RemoveNode(id, withSubtree); err != nil {
+		return err
+	}
+
+	return saveChange[D](ctx, h.repo, flowChart, change)
+}
+
+type HandlerNodeFlowChartUnstructuredData struct {
+	*NodeHandlerFlowChart[transport.UnstructuredDataDto, domain.UnstructuredDataDomain]
+}
+
+func NewHandlerNodeFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerNodeFlowChartUnstructuredData {
+	return HandlerNodeFlowChartUnstructuredData{
+		NewNodeHandlerFlowChart[transport.UnstructuredDataDto, domain.UnstructuredDataDomain](agr,
+			func(request transport.UnstructuredDataDto) domain.UnstructuredDataDomain {
+				return request
+			}),
+	}
+}
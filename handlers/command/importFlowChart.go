@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/importer"
+	"flowChart/transport"
+)
+
+var ErrMissingKey = errors.New("flowchart key is required")
+
+type ImportHandlerFlowChart[D comparable] struct {
+	edit *EditHandlerFlowChart[transport.UnstructuredDataDto, D]
+}
+
+func NewImportHandlerFlowChart[D comparable](edit *EditHandlerFlowChart[transport.UnstructuredDataDto, D]) *ImportHandlerFlowChart[D] {
+	return &ImportHandlerFlowChart[D]{
+		edit: edit,
+	}
+}
+
+// Handler reads the source written in another tool's format and saves it under
+// key the same way a flowchart sent as JSON is saved. Key and title fall back
+// to the ones found in the source, and the title then to the key. Without a
+// version the import only creates a flowchart, so it never replaces one by
+// accident.
+func (h *ImportHandlerFlowChart[D]) Handler(ctx context.Context, key string, title string, format string, source []byte, change transport.ChangeDto) error {
+	dto, err := importer.Import(format, source)
+
+	if err != nil {
+		return err
+	}
+
+	if key != "" {
+		dto.Key = key
+	}
+
+	if dto.Key == "" {
+		return ErrMissingKey
+	}
+
+	if title != "" {
+		dto.Title = title
+	}
+
+	if dto.Title == "" {
+		dto.Title = dto.Key
+	}
+
+	dto.Version = change.Version
+	if dto.Version == nil {
+		dto.Version = new(int)
+	}
+	dto.Author = change.Author
+	dto.Message = change.Message
+
+	return h.edit.Handler(ctx, dto)
+}
+
+type HandlerImportFlowChartUnstructuredData struct {
+	*ImportHandlerFlowChart[domain.UnstructuredDataDomain]
+}
+
+func NewHandlerImportFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerImportFlowChartUnstructuredData {
+	return HandlerImportFlowChartUnstructuredData{
+		NewImportHandlerFlowChart(NewHandlerFlowChartUnstructuredData(agr).EditHandlerFlowChart),
+	}
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/transport"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrPatchFailed  = errors.New("patch could not be applied")
+)
+
+type PatchFormat int
+
+const (
+	// JsonPatch is a list of operations, as described by RFC 6902.
+	JsonPatch PatchFormat = iota
+	// MergePatch is a partial document merged over the flowchart, as described by RFC 7386.
+	MergePatch
+)
+
+type JsonPatchFlowChartRepo[T comparable] interface {
+	GetFlowChart(ctx context.Context, key string) (*adapters.FlowChartModel[T], error)
+	UpdateFlowChart(context.Context, *domain.FlowChart[T]) error
+}
+
+type JsonPatchHandlerFlowChart[T comparable] struct {
+	repo JsonPatchFlowChartRepo[T]
+}
+
+func NewJsonPatchHandlerFlowChart[T comparable](repo JsonPatchFlowChartRepo[T]) *JsonPatchHandlerFlowChart[T] {
+	return &JsonPatchHandlerFlowChart[T]{
+		repo: repo,
+	}
+}
+
+func applyPatch(document []byte, patch []byte, format PatchFormat) ([]byte, error) {
+	if format == MergePatch {
+		patched, err := jsonpatch.MergePatch(document, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return patched, nil
+	}
+
+	operations, err := jsonpatch.DecodePatch(patch)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	patched, err := operations.Apply(document)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPatchFailed, err)
+	}
+
+	return patched, nil
+}
+
+// Handler applies a patch to the JSON returned for the flowchart by the GET
+// endpoint, then validates and saves the result as a new version.
+func (h *JsonPatchHandlerFlowChart[T]) Handler(ctx context.Context, key string, patch []byte, format PatchFormat, change transport.ChangeDto) error {
+	current, err := h.repo.GetFlowChart(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	document, err := json.Marshal(current)
+
+	if err != nil {
+		return fmt.Errorf("error encoding flowchart %w", err)
+	}
+
+	patched, err := applyPatch(document, patch, format)
+
+	if err != nil {
+		return err
+	}
+
+	flow := &adapters.FlowChartModel[T]{}
+
+	if err := json.Unmarshal(patched, flow); err != nil {
+		return fmt.Errorf("%w: %v", ErrPatchFailed, err)
+	}
+
+	if flow.Key != key {
+		return ErrKeyMismatch
+	}
+
+	flowChart, err := flow.ToDomain()
+
+	if err != nil {
+		return fmt.Errorf("error parsing patched flowchart to domain %w", err)
+	}
+
+	flowChart.ExpectedVersion = change.Version
+	if flowChart.ExpectedVersion == nil {
+		flowChart.ExpectedVersion = &current.Version
+	}
+
+	flowChart.Author = change.Author
+	flowChart.Message = change.Message
+
+	return h.repo.UpdateFlowChart(ctx, flowChart)
+}
+
+type HandlerJsonPatchFlowChartUnstructuredData struct {
+	*JsonPatchHandlerFlowChart[domain.UnstructuredDataDomain]
+}
+
+func NewHandlerJsonPatchFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerJsonPatchFlowChartUnstructuredData {
+	return HandlerJsonPatchFlowChartUnstructuredData{
+		NewJsonPatchHandlerFlowChart[domain.UnstructuredDataDomain](agr),
+	}
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/transport"
+
+	"fmt"
+)
+
+var ErrPartialGraphPatch = errors.New("nodes and edges must be patched together")
+
+type PatchFlowChartRepo[T comparable] interface {
+	LoadFlowChart(ctx context.Context, key string) (*domain.FlowChart[T], error)
+	UpdateFlowChart(context.Context, *domain.FlowChart[T]) error
+}
+
+type PatchHandlerFlowChart[R comparable, D comparable] struct {
+	repo        PatchFlowChartRepo[D]
+	dtoToDomain dtoToDomain[R, D]
+	parseData   dataParse[R, D]
+}
+
+func NewPatchHandlerFlowChart[R comparable, D comparable](repo PatchFlowChartRepo[D], parseData dataParse[R, D]) *PatchHandlerFlowChart[R, D] {
+	return &PatchHandlerFlowChart[R, D]{
+		repo:        repo,
+		dtoToDomain: transport.ToDomain[R, D],
+		parseData:   parseData,
+	}
+}
+
+// Handler changes only the parts of an existing flowchart present in dto.
+func (h *PatchHandlerFlowChart[R, D]) Handler(ctx context.Context, key string, dto *transport.FlowChartPatchDto[R]) error {
+	if (dto.Nodes == nil) != (dto.Edges == nil) {
+		return ErrPartialGraphPatch
+	}
+
+	current, err := h.repo.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return err
+	}
+
+	flowChart := current
+
+	if dto.Nodes != nil {
+		flowChart, err = h.dtoToDomain(&transport.FlowChartDto[R]{Key: key, Nodes: dto.Nodes, Edges: dto.Edges}, h.parseData)
+
+		if err != nil {
+			return fmt.Errorf("error parsing dto to domain %w", err)
+		}
+
+		flowChart.Title = current.Title
+		flowChart.Viewport = current.Viewport
+	}
+
+	if dto.Title != nil {
+		flowChart.Title = *dto.Title
+	}
+
+	if dto.Viewport != nil {
+		flowChart.Viewport = transport.ViewportToDomain(dto.Viewport)
+	}
+
+	// Without a version the patch is based on the flowchart loaded above, so a
+	// save landing in between is not overwritten.
+	flowChart.ExpectedVersion = dto.Version
+	if flowChart.ExpectedVersion == nil {
+		flowChart.ExpectedVersion = &current.Version
+	}
+
+	flowChart.Author = dto.Author
+	flowChart.Message = dto.Message
+
+	return h.repo.UpdateFlowChart(ctx, flowChart)
+}
+
+type HandlerPatchFlowChartUnstructuredData struct {
+	*PatchHandlerFlowChart[transport.UnstructuredDataDto, domain.UnstructuredDataDomain]
+}
+
+func NewHandlerPatchFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerPatchFlowChartUnstructuredData {
+	return HandlerPatchFlowChartUnstructuredData{
+		NewPatchHandlerFlowChart[transport.UnstructuredDataDto, domain.UnstructuredDataDomain](agr,
+			func(request transport.UnstructuredDataDto) domain.UnstructuredDataDomain {
+				return request
+			}),
+	}
+}
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/transport"
+
+	"fmt"
+)
+
+var ErrKeyMismatch = errors.New("flowchart key in the body does not match the url")
+
+type ReplaceHandlerFlowChart[R comparable, D comparable] struct {
+	repo        FlowCartRepo[D]
+	dtoToDomain dtoToDomain[R, D]
+	parseData   dataParse[R, D]
+}
+
+func NewReplaceHandlerFlowChart[R comparable, D comparable](repo FlowCartRepo[D], parseData dataParse[R, D]) *ReplaceHandlerFlowChart[R, D] {
+	return &ReplaceHandlerFlowChart[R, D]{
+		repo:        repo,
+		dtoToDomain: transport.ToDomain[R, D],
+		parseData:   parseData,
+	}
+}
+
+// Handler creates the flowchart under key or, when it already exists, replaces
+// it entirely. It reports whether the flowchart was created.
+func (h *ReplaceHandlerFlowChart[R, D]) Handler(ctx context.Context, key string, dto *transport.FlowChartDto[R]) (bool, error) {
+	if dto.Key != "" && dto.Key != key {
+		return false, ErrKeyMismatch
+	}
+
+	dto.Key = key
+
+	flowChart, err := h.dtoToDomain(dto, h.parseData)
+
+	if err != nil {
+		return false, fmt.Errorf("error parsing dto to domain %w", err)
+	}
+
+	exists, err := h.repo.FlowChartExists(ctx, flowChart)
+
+	if err != nil {
+		return false, err
+	}
+
+	if exists {
+		return false, h.repo.UpdateFlowChart(ctx, flowChart)
+	}
+
+	if err := flowChart.CheckVersion(0, false); err != nil {
+		return false, err
+	}
+
+	if err := checkNewKey(flowChart.Key); err != nil {
+		return false, err
+	}
+
+	return true, h.repo.StoreFlowChart(ctx, flowChart)
+}
+
+type HandlerReplaceFlowChartUnstructuredData struct {
+	*ReplaceHandlerFlowChart[transport.UnstructuredDataDto, domain.UnstructuredDataDomain]
+}
+
+func NewHandlerReplaceFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerReplaceFlowChartUnstructuredData {
+	return HandlerReplaceFlowChartUnstructuredData{
+		NewReplaceHandlerFlowChart[transport.UnstructuredDataDto, domain.UnstructuredDataDomain](agr,
+			func(request transport.UnstructuredDataDto) domain.UnstructuredDataDomain {
+				return request
+			}),
+	}
+}
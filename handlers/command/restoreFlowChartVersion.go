@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/transport"
+
+	"fmt"
+)
+
+type FlowChartVersionRepo[T comparable] interface {
+	GetVersion(ctx context.Context, key string, version int) (*adapters.FlowChartModel[T], error)
+	UpdateFlowChart(context.Context, *domain.FlowChart[T]) error
+	FlowChartExists(ctx context.Context, flowChart *domain.FlowChart[T]) (bool, error)
+}
+
+type RestoreVersionHandlerFlowChart[T comparable] struct {
+	repo FlowChartVersionRepo[T]
+}
+
+func NewRestoreVersionHandlerFlowChart[T comparable](repo FlowChartVersionRepo[T]) *RestoreVersionHandlerFlowChart[T] {
+	return &RestoreVersionHandlerFlowChart[T]{
+		repo: repo,
+	}
+}
+
+// Handler saves an old version as the new head of the flowchart and returns the
+// number of the version it creates.
+func (h *RestoreVersionHandlerFlowChart[T]) Handler(ctx context.Context, key string, version int, dto *transport.RestoreVersionDto) (int, error) {
+	snapshot, err := h.repo.GetVersion(ctx, key, version)
+
+	if err != nil {
+		return 0, err
+	}
+
+	flowChart, err := snapshot.ToDomain()
+
+	if err != nil {
+		return 0, fmt.Errorf("error parsing version %d to domain %w", version, err)
+	}
+
+	flowChart.Author = dto.Author
+	flowChart.Message = dto.Message
+
+	if flowChart.Message == "" {
+		flowChart.Message = fmt.Sprintf("Restore version %d", version)
+	}
+
+	exists, err := h.repo.FlowChartExists(ctx, flowChart)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		return 0, domain.ErrFlowChartNotFound
+	}
+
+	if err := h.repo.UpdateFlowChart(ctx, flowChart); err != nil {
+		return 0, err
+	}
+
+	return flowChart.Version, nil
+}
+
+type HandlerRestoreFlowChartUnstructuredData struct {
+	*RestoreVersionHandlerFlowChart[domain.UnstructuredDataDomain]
+}
+
+func NewHandlerRestoreFlowChartUnstructuredData(agr *adapters.WriteFlowChartUnstructuredDataAgg) HandlerRestoreFlowChartUnstructuredData {
+	return HandlerRestoreFlowChartUnstructuredData{
+		NewRestoreVersionHandlerFlowChart[domain.UnstructuredDataDomain](agr),
+	}
+}
@@ -0,0 +1,42 @@
+package queries
+
+import (
+	"context"
+	"flowChart/adapters"
+	"flowChart/domain"
+	"flowChart/exporter"
+)
+
+type ExportFlowChartAggregate[T any] interface {
+	LoadFlowChart(ctx context.Context, key string) (*domain.FlowChart[T], error)
+}
+
+type HandlerExportFlowChart[T any] struct {
+	agg ExportFlowChartAggregate[T]
+}
+
+func NewExportFlowChartHandler[T any](agg ExportFlowChartAggregate[T]) *HandlerExportFlowChart[T] {
+	return &HandlerExportFlowChart[T]{
+		agg: agg,
+	}
+}
+
+func (h *HandlerExportFlowChart[T]) Handler(ctx context.Context, key string, format string, options exporter.Options) (*exporter.Document, error) {
+	flowChart, err := h.agg.LoadFlowChart(ctx, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return exporter.Export(flowChart, format, options)
+}
+
+type HandlerExportFlowChartUnstructuredData struct {
+	*HandlerExportFlowChart[adapters.WagtailDataModel]
+}
+
+func NewHandlerExportFlowChartUnstructuredData(agr *adapters.ReadFlowChartUnstructuredDataAgg) HandlerExportFlowChartUnstructuredData {
+	return HandlerExportFlowChartUnstructuredData{
+		NewExportFlowChartHandler[adapters.WagtailDataModel](agr),
+	}
+}
@@ -0,0 +1,59 @@
+package queries
+
+import (
+	"context"
+	"flowChart/adapters"
+)
+
+type QueryFlowChartVersionAggregate[T any] interface {
+	ListVersions(ctx context.Context, key string) ([]*adapters.VersionModel, error)
+	GetVersion(ctx context.Context, key string, version int) (*adapters.FlowChartModel[T], error)
+}
+
+type HandlerListFlowChartVersions[T any] struct {
+	agg QueryFlowChartVersionAggregate[T]
+}
+
+func NewListFlowChartVersionsHandler[T any](agg QueryFlowChartVersionAggregate[T]) *HandlerListFlowChartVersions[T] {
+	return &HandlerListFlowChartVersions[T]{
+		agg: agg,
+	}
+}
+
+func (h *HandlerListFlowChartVersions[T]) Handler(ctx context.Context, key string) ([]*adapters.VersionModel, error) {
+	return h.agg.ListVersions(ctx, key)
+}
+
+type HandlerGetFlowChartVersion[T any] struct {
+	agg QueryFlowChartVersionAggregate[T]
+}
+
+func NewGetFlowChartVersionHandler[T any](agg QueryFlowChartVersionAggregate[T]) *HandlerGetFlowChartVersion[T] {
+	return &HandlerGetFlowChartVersion[T]{
+		agg: agg,
+	}
+}
+
+func (h *HandlerGetFlowChartVersion[T]) Handler(ctx context.Context, key string, version int) (*adapters.FlowChartModel[T], error) {
+	return h.agg.GetVersion(ctx, key, version)
+}
+
+type HandlerListFlowChartVersionsUnstructuredData struct {
+	*HandlerListFlowChartVersions[adapters.WagtailDataModel]
+}
+
+func NewHandlerListFlowChartVersionsUnstructuredData(agr *adapters.ReadFlowChartUnstructuredDataAgg) HandlerListFlowChartVersionsUnstructuredData {
+	return HandlerListFlowChartVersionsUnstructuredData{
+		NewListFlowChartVersionsHandler[adapters.WagtailDataModel](agr),
+	}
+}
+
+type HandlerGetFlowChartVersionUnstructuredData struct {
+	*HandlerGetFlowChartVersion[adapters.WagtailDataModel]
+}
+
+func NewHandlerGetFlowChartVersionUnstructuredData(agr *adapters.ReadFlowChartUnstructuredDataAgg) HandlerGetFlowChartVersionUnstructuredData {
+	return HandlerGetFlowChartVersionUnstructuredData{
+		NewGetFlowChartVersionHandler[adapters.WagtailDataModel](agr),
+	}
+}
@@ -0,0 +1,63 @@
+package queries
+
+import (
+	"context"
+	"flowChart/adapters"
+	"flowChart/domain"
+)
+
+type ListFlowChartAggregate interface {
+	ListFlowCharts(ctx context.Context, limit int, offset int) (*adapters.FlowChartPageModel, error)
+}
+
+type HandlerListFlowCharts struct {
+	agg ListFlowChartAggregate
+}
+
+func NewListFlowChartsHandler(agg ListFlowChartAggregate) *HandlerListFlowCharts {
+	return &HandlerListFlowCharts{
+		agg: agg,
+	}
+}
+
+func (h *HandlerListFlowCharts) Handler(ctx context.Context, limit int, offset int) (*adapters.FlowChartPageModel, error) {
+	return h.agg.ListFlowCharts(ctx, limit, offset)
+}
+
+type ExistsFlowChartAggregate[T any] interface {
+	FlowChartExists(ctx context.Context, flowChart *domain.FlowChart[T]) (bool, error)
+}
+
+type HandlerFlowChartExists[T any] struct {
+	agg ExistsFlowChartAggregate[T]
+}
+
+func NewFlowChartExistsHandler[T any](agg ExistsFlowChartAggregate[T]) *HandlerFlowChartExists[T] {
+	return &HandlerFlowChartExists[T]{
+		agg: agg,
+	}
+}
+
+func (h *HandlerFlowChartExists[T]) Handler(ctx context.Context, key string) (bool, error) {
+	return h.agg.FlowChartExists(ctx, &domain.FlowChart[T]{Key: key})
+}
+
+type HandlerListFlowChartsUnstructuredData struct {
+	*HandlerListFlowCharts
+}
+
+func NewHandlerListFlowChartsUnstructuredData(agr *adapters.ReadFlowChartUnstructuredDataAgg) HandlerListFlowChartsUnstructuredData {
+	return HandlerListFlowChartsUnstructuredData{
+		NewListFlowChartsHandler(agr),
+	}
+}
+
+type HandlerFlowChartExistsUnstructuredData struct {
+	*HandlerFlowChartExists[adapters.WagtailDataModel]
+}
+
+func NewHandlerFlowChartExistsUnstructuredData(agr *adapters.ReadFlowChartUnstructuredDataAgg) HandlerFlowChartExistsUnstructuredData {
+	return HandlerFlowChartExistsUnstructuredData{
+		NewFlowChartExistsHandler[adapters.WagtailDataModel](agr),
+	}
+}
@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flowChart/domain"
+	"flowChart/transport"
+)
+
+const bpmnModelNamespace = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+
+// bpmnNodeTypes maps BPMN flow elements to node types. Flow elements missing
+// from it, such as parallel gateways or intermediate events, become default
+// nodes so the flow through them stays connected.
+var bpmnNodeTypes = map[string]string{
+	"startEvent":       domain.InputNodeType,
+	"endEvent":         "output",
+	"exclusiveGateway": "decision",
+}
+
+// bpmnFlowNodes are the process elements that become nodes.
+var bpmnFlowNodes = map[string]bool{
+	"startEvent":             true,
+	"endEvent":               true,
+	"intermediateCatchEvent": true,
+	"intermediateThrowEvent": true,
+	"boundaryEvent":          true,
+	"task":                   true,
+	"userTask":               true,
+	"serviceTask":            true,
+	"scriptTask":             true,
+	"manualTask":             true,
+	"sendTask":               true,
+	"receiveTask":            true,
+	"businessRuleTask":       true,
+	"callActivity":           true,
+	"subProcess":             true,
+	"exclusiveGateway":       true,
+	"parallelGateway":        true,
+	"inclusiveGateway":       true,
+	"eventBasedGateway":      true,
+	"complexGateway":         true,
+}
+
+type bpmnDefinitions struct {
+	XMLName   xml.Name      `xml:"http://www.omg.org/spec/BPMN/20100524/MODEL definitions"`
+	Processes []bpmnProcess `xml:"http://www.omg.org/spec/BPMN/20100524/MODEL process"`
+	Diagrams  []bpmnDiagram `xml:"http://www.omg.org/spec/BPMN/20100524/DI BPMNDiagram"`
+}
+
+type bpmnProcess struct {
+	ID       string        `xml:"id,attr"`
+	Name     string        `xml:"name,attr"`
+	Elements []bpmnElement `xml:",any"`
+}
+
+type bpmnElement struct {
+	XMLName   xml.Name
+	ID        string `xml:"id,attr"`
+	Name      string `xml:"name,attr"`
+	SourceRef string `xml:"sourceRef,attr"`
+	TargetRef string `xml:"targetRef,attr"`
+}
+
+type bpmnDiagram struct {
+	Planes []bpmnPlane `xml:"http://www.omg.org/spec/BPMN/20100524/DI BPMNPlane"`
+}
+
+type bpmnPlane struct {
+	Shapes []bpmnShape `xml:"http://www.omg.org/spec/BPMN/20100524/DI BPMNShape"`
+}
+
+type bpmnShape struct {
+	Element string      `xml:"bpmnElement,attr"`
+	Bounds  *bpmnBounds `xml:"http://www.omg.org/spec/DD/20100524/DC Bounds"`
+}
+
+type bpmnBounds struct {
+	X      float64 `xml:"x,attr"`
+	Y      float64 `xml:"y,attr"`
+	Width  float64 `xml:"width,attr"`
+	Height float64 `xml:"height,attr"`
+}
+
+// Bpmn reads the first process of a BPMN 2.0 document that has flow elements.
+// Start and end events, tasks and exclusive gateways become nodes of the
+// matching type and sequence flows become edges. The bounds of the diagram
+// shapes give the position and size of the nodes; when any shape is missing
+// the nodes are laid out from left to right instead.
+func Bpmn(source []byte) (*transport.FlowChartDto[transport.UnstructuredDataDto], error) {
+	definitions := &bpmnDefinitions{}
+
+	if err := decodeXML(BpmnFormat, source, definitions); err != nil {
+		return nil, err
+	}
+
+	var process *bpmnProcess
+	for i := range definitions.Processes {
+		if bpmnHasFlowNodes(&definitions.Processes[i]) {
+			process = &definitions.Processes[i]
+			break
+		}
+	}
+
+	if process == nil {
+		return nil, &SyntaxError{Format: BpmnFormat, Message: "no process with flow elements"}
+	}
+
+	bounds := map[string]*bpmnBounds{}
+	for _, diagram := range definitions.Diagrams {
+		for _, plane := range diagram.Planes {
+			for _, shape := range plane.Shapes {
+				if shape.Bounds != nil {
+					bounds[shape.Element] = shape.Bounds
+				}
+			}
+		}
+	}
+
+	flowChart := &transport.FlowChartDto[transport.UnstructuredDataDto]{Title: process.Name}
+	placed := true
+
+	for _, element := range process.Elements {
+		if element.XMLName.Space != bpmnModelNamespace || !bpmnFlowNodes[element.XMLName.Local] {
+			continue
+		}
+
+		nodeType, ok := bpmnNodeTypes[element.XMLName.Local]
+		if !ok {
+			nodeType = "default"
+		}
+
+		label := element.Name
+		if label == "" {
+			label = element.ID
+		}
+
+		node := &transport.NodeDto[transport.UnstructuredDataDto]{Id: element.ID, Data: labelData(label), Type: nodeType}
+
+		if box, ok := bounds[element.ID]; ok {
+			node.Position = transport.PositionDto{X: box.X, Y: box.Y}
+			node.PositionAbsolute = node.Position
+			node.Width = box.Width
+			node.Height = box.Height
+		} else {
+			placed = false
+		}
+
+		flowChart.Nodes = append(flowChart.Nodes, node)
+	}
+
+	for _, element := range process.Elements {
+		if element.XMLName.Space != bpmnModelNamespace || element.XMLName.Local != "sequenceFlow" {
+			continue
+		}
+
+		flowChart.Edges = append(flowChart.Edges, &transport.EdgeDto{
+			Id:        element.ID,
+			Source:    element.SourceRef,
+			Target:    element.TargetRef,
+			Label:     element.Name,
+			MarkerEnd: json.RawMessage(`{"type":"arrowclosed"}`),
+		})
+	}
+
+	if !placed {
+		layout(flowChart, "LR", nil)
+	}
+
+	return flowChart, nil
+}
+
+func bpmnHasFlowNodes(process *bpmnProcess) bool {
+	for _, element := range process.Elements {
+		if element.XMLName.Space == bpmnModelNamespace && bpmnFlowNodes[element.XMLName.Local] {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeXML reads an XML document into v, reporting where the document stops
+// being valid XML.
+func decodeXML(format string, source []byte, v any) error {
+	decoder := xml.NewDecoder(bytes.NewReader(source))
+
+	err := decoder.Decode(v)
+
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &SyntaxError{Format: format, Line: syntaxErr.Line, Message: syntaxErr.Msg}
+	}
+
+	line, _ := decoder.InputPos()
+	return &SyntaxError{Format: format, Line: line, Message: err.Error()}
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBpmn(t *testing.T) {
+	source := `<?xml version="1.0" encoding="UTF-8"?>
+<definitions xmlns="http://www.omg.org/spec/BPMN/20100524/MODEL"
+             xmlns:bpmndi="http://www.omg.org/spec/BPMN/20100524/DI"
+             xmlns:dc="http://www.omg.org/spec/DD/20100524/DC">
+  <process id="order" name="Order">
+    <startEvent id="start" name="Received"/>
+    <exclusiveGateway id="paid" name="Paid?"/>
+    <task id="ship" name="Ship"/>
+    <endEvent id="done"/>
+    <sequenceFlow id="f1" sourceRef="start" targetRef="paid"/>
+    <sequenceFlow id="f2" sourceRef="paid" targetRef="ship" name="yes"/>
+    <sequenceFlow id="f3" sourceRef="ship" targetRef="done"/>
+  </process>
+  <bpmndi:BPMNDiagram>
+    <bpmndi:BPMNPlane>
+      <bpmndi:BPMNShape bpmnElement="start"><dc:Bounds x="10" y="20" width="36" height="36"/></bpmndi:BPMNShape>
+      <bpmndi:BPMNShape bpmnElement="paid"><dc:Bounds x="100" y="15" width="50" height="50"/></bpmndi:BPMNShape>
+      <bpmndi:BPMNShape bpmnElement="ship"><dc:Bounds x="200" y="0" width="100" height="80"/></bpmndi:BPMNShape>
+      <bpmndi:BPMNShape bpmnElement="done"><dc:Bounds x="350" y="20" width="36" height="36"/></bpmndi:BPMNShape>
+    </bpmndi:BPMNPlane>
+  </bpmndi:BPMNDiagram>
+</definitions>`
+
+	flowChart, err := Import(BpmnFormat, []byte(source))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if flowChart.Title != "Order" {
+		t.Errorf("title = %q, want %q", flowChart.Title, "Order")
+	}
+
+	want := map[string]string{"start": "input", "paid": "decision", "ship": "default", "done": "output"}
+	if got := nodeTypes(flowChart); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("node types = %v, want %v", got, want)
+	}
+
+	ship := flowChart.Nodes[2]
+	if ship.Position.X != 200 || ship.Width != 100 || ship.Height != 80 {
+		t.Errorf("ship placed at %v with size %gx%g, want the BPMN bounds", ship.Position, ship.Width, ship.Height)
+	}
+
+	if got := fmt.Sprint(edgeEnds(flowChart)); got != "[start>paid paid>ship ship>done]" {
+		t.Errorf("edges = %s", got)
+	}
+
+	if root := toDomain(t, flowChart).Node.NodeID; root != "start" {
+		t.Errorf("root = %q, want %q", root, "start")
+	}
+}
@@ -0,0 +1,334 @@
+package importer
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"flowChart/domain"
+	"flowChart/transport"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	drawioDefaultPage = regexp.MustCompile(`^Page-\d+$`)
+	drawioBreak       = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+	drawioTag         = regexp.MustCompile(`<[^>]*>`)
+)
+
+// drawioNodeTypes maps draw.io shapes to node types. Other shapes become
+// default nodes.
+var drawioNodeTypes = map[string]string{
+	"rhombus":       "decision",
+	"ellipse":       "output",
+	"doubleEllipse": "output",
+	"group":         "group",
+	"swimlane":      "group",
+}
+
+type drawioDocument struct {
+	XMLName  xml.Name
+	Diagrams []drawioDiagram `xml:"diagram"`
+	Root     drawioRoot      `xml:"root"`
+}
+
+type drawioDiagram struct {
+	Name    string       `xml:"name,attr"`
+	Model   *drawioModel `xml:"mxGraphModel"`
+	Content string       `xml:",chardata"`
+}
+
+type drawioModel struct {
+	Root drawioRoot `xml:"root"`
+}
+
+type drawioRoot struct {
+	Items []drawioCell `xml:",any"`
+}
+
+// drawioCell is an mxCell, or a UserObject or object element wrapping one to
+// attach data to it.
+type drawioCell struct {
+	XMLName  xml.Name
+	ID       string          `xml:"id,attr"`
+	Value    string          `xml:"value,attr"`
+	Label    string          `xml:"label,attr"`
+	Style    string          `xml:"style,attr"`
+	Vertex   string          `xml:"vertex,attr"`
+	Edge     string          `xml:"edge,attr"`
+	Parent   string          `xml:"parent,attr"`
+	Source   string          `xml:"source,attr"`
+	Target   string          `xml:"target,attr"`
+	Geometry *drawioGeometry `xml:"mxGeometry"`
+	Cell     *drawioCell     `xml:"mxCell"`
+}
+
+type drawioGeometry struct {
+	X      float64 `xml:"x,attr"`
+	Y      float64 `xml:"y,attr"`
+	Width  float64 `xml:"width,attr"`
+	Height float64 `xml:"height,attr"`
+}
+
+// unwrap returns the mxCell of a UserObject with the id and label of the
+// wrapper.
+func (c drawioCell) unwrap() drawioCell {
+	if c.Cell == nil {
+		return c
+	}
+
+	cell := *c.Cell
+	cell.ID = c.ID
+	cell.Value = c.Label
+	return cell
+}
+
+// Drawio reads the first page of a draw.io file, compressed or not, or a bare
+// mxGraphModel. Vertices become nodes, placed by their geometry and typed by
+// their shape, and vertices inside another one become nodes of that group.
+// Edges between vertices become edges, and text vertices no edge touches are
+// left out.
+func Drawio(source []byte) (*transport.FlowChartDto[transport.UnstructuredDataDto], error) {
+	document := &drawioDocument{}
+
+	if err := decodeXML(DrawioFormat, source, document); err != nil {
+		return nil, err
+	}
+
+	root := document.Root
+	title := ""
+
+	switch document.XMLName.Local {
+	case "mxGraphModel":
+	case "mxfile":
+		if len(document.Diagrams) == 0 {
+			return nil, &SyntaxError{Format: DrawioFormat, Message: "file has no diagram"}
+		}
+
+		diagram := document.Diagrams[0]
+		if !drawioDefaultPage.MatchString(diagram.Name) {
+			title = diagram.Name
+		}
+
+		model := diagram.Model
+		if model == nil {
+			inflated, err := drawioInflate(diagram.Content)
+
+			if err != nil {
+				return nil, &SyntaxError{Format: DrawioFormat, Message: "cannot decompress diagram: " + err.Error()}
+			}
+
+			model = &drawioModel{}
+			if err := decodeXML(DrawioFormat, inflated, model); err != nil {
+				return nil, err
+			}
+		}
+
+		root = model.Root
+	default:
+		return nil, &SyntaxError{Format: DrawioFormat, Line: 1, Message: "expected mxfile or mxGraphModel, found " + document.XMLName.Local}
+	}
+
+	cells := make([]drawioCell, 0, len(root.Items))
+	byID := make(map[string]drawioCell, len(root.Items))
+	for _, item := range root.Items {
+		cell := item.unwrap()
+		cells = append(cells, cell)
+		byID[cell.ID] = cell
+	}
+
+	flowChart := &transport.FlowChartDto[transport.UnstructuredDataDto]{Title: title}
+	connected := map[string]bool{}
+	edgeLabels := map[string]string{}
+
+	for _, cell := range cells {
+		if cell.Edge == "1" && cell.Source != "" && cell.Target != "" {
+			connected[cell.Source] = true
+			connected[cell.Target] = true
+		}
+
+		if parent, ok := byID[cell.Parent]; ok && cell.Vertex == "1" && parent.Edge == "1" {
+			edgeLabels[parent.ID] = drawioLabel(cell)
+		}
+	}
+
+	for _, cell := range cells {
+		if cell.Vertex != "1" {
+			continue
+		}
+
+		style := drawioStyle(cell.Style)
+		parent, ok := byID[cell.Parent]
+
+		if ok && parent.Edge == "1" {
+			continue
+		}
+
+		if style.shape == "text" && !connected[cell.ID] {
+			continue
+		}
+
+		label := drawioLabel(cell)
+		if label == "" {
+			label = cell.ID
+		}
+
+		node := &transport.NodeDto[transport.UnstructuredDataDto]{Id: cell.ID, Data: labelData(label), Type: style.nodeType()}
+
+		if ok && parent.Vertex == "1" {
+			node.ParentNode = parent.ID
+		}
+
+		if cell.Geometry != nil {
+			node.Position = transport.PositionDto{X: cell.Geometry.X, Y: cell.Geometry.Y}
+			node.Width = cell.Geometry.Width
+			node.Height = cell.Geometry.Height
+		}
+
+		node.PositionAbsolute = drawioAbsolute(cell, byID)
+
+		if node.Type == "group" {
+			node.Style = json.RawMessage(fmt.Sprintf(`{"width":%g,"height":%g}`, node.Width, node.Height))
+		}
+
+		flowChart.Nodes = append(flowChart.Nodes, node)
+	}
+
+	for _, cell := range cells {
+		if cell.Edge != "1" || cell.Source == "" || cell.Target == "" {
+			continue
+		}
+
+		style := drawioStyle(cell.Style)
+		label := drawioLabel(cell)
+		if label == "" {
+			label = edgeLabels[cell.ID]
+		}
+
+		edge := &transport.EdgeDto{Id: cell.ID, Source: cell.Source, Target: cell.Target, Label: label}
+
+		if style.values["endArrow"] != "none" {
+			edge.MarkerEnd = json.RawMessage(`{"type":"arrowclosed"}`)
+		}
+
+		if arrow, ok := style.values["startArrow"]; ok && arrow != "none" {
+			edge.MarkerStart = json.RawMessage(`{"type":"arrowclosed"}`)
+		}
+
+		if style.values["dashed"] == "1" {
+			edge.Style = json.RawMessage(`{"strokeDasharray":"5 5"}`)
+		}
+
+		flowChart.Edges = append(flowChart.Edges, edge)
+	}
+
+	return flowChart, nil
+}
+
+// drawioInflate decodes a compressed diagram: the URI encoded XML, deflated
+// and then base64 encoded.
+func drawioInflate(content string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+
+	if err != nil {
+		return nil, err
+	}
+
+	inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(inflated, []byte("%3C")) {
+		return inflated, nil
+	}
+
+	decoded, err := url.PathUnescape(string(inflated))
+	return []byte(decoded), err
+}
+
+// drawioAbsolute adds up the geometry of a cell and of the vertices it is in.
+func drawioAbsolute(cell drawioCell, byID map[string]drawioCell) transport.PositionDto {
+	position := transport.PositionDto{}
+
+	for seen := map[string]bool{}; !seen[cell.ID]; {
+		seen[cell.ID] = true
+
+		if cell.Geometry != nil {
+			position.X += cell.Geometry.X
+			position.Y += cell.Geometry.Y
+		}
+
+		parent, ok := byID[cell.Parent]
+		if !ok || parent.Vertex != "1" {
+			break
+		}
+		cell = parent
+	}
+
+	return position
+}
+
+// drawioLabel reads the text of a cell, which is HTML when its style says so.
+func drawioLabel(cell drawioCell) string {
+	if drawioStyle(cell.Style).values["html"] != "1" {
+		return cell.Value
+	}
+
+	text := drawioBreak.ReplaceAllString(cell.Value, "\n")
+	text = drawioTag.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+type drawioCellStyle struct {
+	shape  string
+	values map[string]string
+}
+
+// drawioStyle reads a style such as rhombus;whiteSpace=wrap;html=1; where the
+// shape is either the leading bare name or the shape key.
+func drawioStyle(style string) drawioCellStyle {
+	parsed := drawioCellStyle{values: map[string]string{}}
+
+	for _, part := range strings.Split(style, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+
+		if !found {
+			if parsed.shape == "" && key != "" {
+				parsed.shape = key
+			}
+			continue
+		}
+
+		parsed.values[key] = value
+	}
+
+	if shape, ok := parsed.values["shape"]; ok {
+		parsed.shape = shape
+	}
+
+	return parsed
+}
+
+func (s drawioCellStyle) nodeType() string {
+	if s.values["container"] == "1" {
+		return "group"
+	}
+
+	if s.values["rounded"] == "1" && s.values["arcSize"] == "50" {
+		return domain.InputNodeType
+	}
+
+	if nodeType, ok := drawioNodeTypes[s.shape]; ok {
+		return nodeType
+	}
+
+	return "default"
+}
@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDrawio(t *testing.T) {
+	source := `<mxfile><diagram name="Review"><mxGraphModel><root>
+  <mxCell id="0"/>
+  <mxCell id="1" parent="0"/>
+  <mxCell id="box" value="Team" style="swimlane;" vertex="1" parent="1"><mxGeometry x="100" y="50" width="300" height="200" as="geometry"/></mxCell>
+  <mxCell id="start" value="&lt;b&gt;Start&lt;/b&gt;" style="rounded=1;arcSize=50;html=1;" vertex="1" parent="1"><mxGeometry x="0" y="0" width="120" height="40" as="geometry"/></mxCell>
+  <mxCell id="check" value="Check" style="rhombus;" vertex="1" parent="box"><mxGeometry x="20" y="30" width="80" height="80" as="geometry"/></mxCell>
+  <mxCell id="note" value="loose text" style="text;" vertex="1" parent="1"><mxGeometry x="500" y="0" width="60" height="20" as="geometry"/></mxCell>
+  <mxCell id="e1" style="dashed=1;" edge="1" parent="1" source="start" target="check"/>
+  <mxCell id="e1-label" value="go" style="edgeLabel;" vertex="1" parent="e1"><mxGeometry as="geometry"/></mxCell>
+</root></mxGraphModel></diagram></mxfile>`
+
+	flowChart, err := Import(DrawioFormat, []byte(source))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if flowChart.Title != "Review" {
+		t.Errorf("title = %q, want %q", flowChart.Title, "Review")
+	}
+
+	want := map[string]string{"box": "group", "start": "input", "check": "decision"}
+	if got := nodeTypes(flowChart); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("node types = %v, want %v", got, want)
+	}
+
+	for _, node := range flowChart.Nodes {
+		switch node.Id {
+		case "start":
+			if label := node.Data.(map[string]any)["label"]; label != "Start" {
+				t.Errorf("start label = %q, want the HTML stripped", label)
+			}
+		case "check":
+			if node.ParentNode != "box" || node.PositionAbsolute.X != 120 || node.PositionAbsolute.Y != 80 {
+				t.Errorf("check parent %q at %v, want inside box at {120 80}", node.ParentNode, node.PositionAbsolute)
+			}
+		}
+	}
+
+	if len(flowChart.Edges) != 1 {
+		t.Fatalf("edges = %v, want one", edgeEnds(flowChart))
+	}
+
+	edge := flowChart.Edges[0]
+	if edge.Label != "go" || string(edge.Style) != `{"strokeDasharray":"5 5"}` {
+		t.Errorf("edge label %q style %s, want the label cell and a dashed stroke", edge.Label, edge.Style)
+	}
+
+	toDomain(t, flowChart)
+}
@@ -0,0 +1,58 @@
+// Package importer reads flowcharts written for other tools into the flowchart
+// sent to the commands, so they are stored like any flowchart drawn in the
+// editor.
+package importer
+
+import (
+	"errors"
+	"flowChart/transport"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("unknown import format")
+
+const (
+	MermaidFormat = "mermaid"
+	BpmnFormat    = "bpmn"
+	DrawioFormat  = "drawio"
+	YamlFormat    = "yaml"
+)
+
+// SyntaxError points to the line of the source that could not be read, when
+// there is one.
+type SyntaxError struct {
+	Format  string
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("invalid %s source: %s", e.Format, e.Message)
+	}
+
+	return fmt.Sprintf("invalid %s source at line %d: %s", e.Format, e.Line, e.Message)
+}
+
+// Import reads the source written in the given format. Only YAML sources carry
+// the key of the flowchart; for the other formats it is given by the caller.
+func Import(format string, source []byte) (*transport.FlowChartDto[transport.UnstructuredDataDto], error) {
+	switch strings.ToLower(format) {
+	case MermaidFormat:
+		return Mermaid(string(source))
+	case BpmnFormat:
+		return Bpmn(source)
+	case DrawioFormat:
+		return Drawio(source)
+	case YamlFormat:
+		return Yaml(source)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+// labelData is the node data React Flow shows the label of.
+func labelData(label string) transport.UnstructuredDataDto {
+	return map[string]any{"label": label}
+}
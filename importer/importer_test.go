@@ -0,0 +1,38 @@
+package importer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImportUnknownFormat(t *testing.T) {
+	if _, err := Import("visio", nil); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Import() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestImportSyntaxErrorLine(t *testing.T) {
+	tests := []struct {
+		format string
+		source string
+		line   int
+	}{
+		{MermaidFormat, "flowchart TD\nsubgraph one\na --> b", 3},
+		{MermaidFormat, "sequenceDiagram\nA->>B: hi", 1},
+		{YamlFormat, "key: k\nnodes:\n  - id: a\n    colour: red\n", 4},
+	}
+
+	for _, tt := range tests {
+		_, err := Import(tt.format, []byte(tt.source))
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Import(%s) error = %v, want a SyntaxError", tt.format, err)
+			continue
+		}
+
+		if syntaxErr.Line != tt.line {
+			t.Errorf("Import(%s) error line = %d, want %d (%v)", tt.format, syntaxErr.Line, tt.line, err)
+		}
+	}
+}
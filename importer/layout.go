@@ -0,0 +1,371 @@
+package importer
+
+import (
+	"encoding/json"
+	"flowChart/transport"
+	"fmt"
+	"math"
+)
+
+const (
+	// nodeWidth and nodeHeight are the sizes React Flow gives a default node.
+	nodeWidth  = 150
+	nodeHeight = 40
+
+	layerGap     = 60
+	siblingGap   = 40
+	groupPadding = 20
+	// groupHeader leaves room above the nodes of a group for its label.
+	groupHeader = 30
+)
+
+type layoutBox struct {
+	x, y, width, height float64
+}
+
+type layoutScope struct {
+	direction string
+	nodes     []*transport.NodeDto[transport.UnstructuredDataDto]
+	byID      map[string]*transport.NodeDto[transport.UnstructuredDataDto]
+	children  map[string][]string
+	edges     []*transport.EdgeDto
+	boxes     map[string]layoutBox
+	kept      map[string]bool
+}
+
+// layout places the nodes in layers, every node one layer past the furthest
+// node leading to it, with layers stacked in the direction of the flowchart:
+// TB, TD, BT, LR or RL. A group is laid out the same way inside, then placed
+// as a single block, so it never overlaps nodes outside it. Positions inside a
+// group are relative to the group, as React Flow expects.
+//
+// The nodes in kept are left where they are, with their size and style, and
+// the others are laid out past them.
+func layout(flowChart *transport.FlowChartDto[transport.UnstructuredDataDto], direction string, kept map[string]bool) {
+	scope := &layoutScope{
+		direction: direction,
+		kept:      kept,
+		nodes:     flowChart.Nodes,
+		byID:      make(map[string]*transport.NodeDto[transport.UnstructuredDataDto], len(flowChart.Nodes)),
+		children:  map[string][]string{},
+		edges:     flowChart.Edges,
+		boxes:     make(map[string]layoutBox, len(flowChart.Nodes)),
+	}
+
+	for _, node := range flowChart.Nodes {
+		scope.byID[node.Id] = node
+	}
+
+	for _, node := range flowChart.Nodes {
+		parent := node.ParentNode
+		if _, ok := scope.byID[parent]; !ok {
+			parent = ""
+		}
+		scope.children[parent] = append(scope.children[parent], node.Id)
+	}
+
+	scope.place("")
+	scope.apply("", transport.PositionDto{})
+}
+
+// place lays out the children of a group, or of the flowchart when group is
+// empty, and returns the size they take.
+func (s *layoutScope) place(group string) (float64, float64) {
+	var ids []string
+	horizontal := s.direction == "LR" || s.direction == "RL"
+
+	// kept spans the children left where they are.
+	kept, hasKept := layoutBox{}, false
+
+	sizes := make(map[string]layoutBox, len(s.children[group]))
+	for _, id := range s.children[group] {
+		size := layoutBox{width: nodeWidth, height: nodeHeight}
+
+		if len(s.children[id]) > 0 {
+			size.width, size.height = s.place(id)
+		}
+
+		if s.kept[id] {
+			kept, hasKept = s.keep(id, kept, hasKept), true
+			continue
+		}
+
+		if horizontal {
+			size.width, size.height = size.height, size.width
+		}
+
+		// From here on width is the extent across the flow and height along it.
+		sizes[id] = size
+		ids = append(ids, id)
+	}
+
+	var edges []*transport.EdgeDto
+	for _, edge := range s.edges {
+		source, target := s.childOf(group, edge.Source), s.childOf(group, edge.Target)
+
+		if source != "" && target != "" && source != target {
+			edges = append(edges, &transport.EdgeDto{Source: source, Target: target})
+		}
+	}
+
+	boxes := make(map[string]layoutBox, len(ids))
+	main, maxCross := 0.0, 0.0
+
+	for _, layer := range layerNodes(ids, edges) {
+		depth, cross := 0.0, 0.0
+
+		for i, id := range layer {
+			if i > 0 {
+				cross += siblingGap
+			}
+
+			boxes[id] = layoutBox{x: cross, y: main, width: sizes[id].width, height: sizes[id].height}
+			cross += sizes[id].width
+			depth = math.Max(depth, sizes[id].height)
+		}
+
+		// Layers are centred across each other.
+		for _, id := range layer {
+			box := boxes[id]
+			box.x -= cross / 2
+			boxes[id] = box
+		}
+
+		main += depth + layerGap
+		maxCross = math.Max(maxCross, cross)
+	}
+
+	main = math.Max(main-layerGap, 0)
+
+	width, height := maxCross, main
+	if horizontal {
+		width, height = main, maxCross
+	}
+
+	origin := transport.PositionDto{}
+	if group != "" {
+		origin = transport.PositionDto{X: groupPadding, Y: groupPadding + groupHeader}
+	}
+
+	// Nodes laid out next to kept ones follow them in the direction of the flow.
+	if hasKept && len(ids) > 0 {
+		origin = transport.PositionDto{X: kept.x, Y: kept.y}
+
+		switch s.direction {
+		case "BT":
+			origin.Y -= height + layerGap
+		case "LR":
+			origin.X += kept.width + layerGap
+		case "RL":
+			origin.X -= width + layerGap
+		default:
+			origin.Y += kept.height + layerGap
+		}
+	}
+
+	for _, id := range ids {
+		box := boxes[id]
+		box.x += maxCross / 2
+
+		if s.direction == "BT" || s.direction == "RL" {
+			box.y = main - box.y - box.height
+		}
+
+		if horizontal {
+			box = layoutBox{x: box.y, y: box.x, width: box.height, height: box.width}
+		}
+
+		box.x += origin.X
+		box.y += origin.Y
+
+		s.boxes[id] = box
+	}
+
+	if !hasKept {
+		return width + 2*groupPadding, height + 2*groupPadding + groupHeader
+	}
+
+	right, bottom := kept.x+kept.width, kept.y+kept.height
+	for _, id := range ids {
+		right = math.Max(right, s.boxes[id].x+s.boxes[id].width)
+		bottom = math.Max(bottom, s.boxes[id].y+s.boxes[id].height)
+	}
+
+	return right + groupPadding, bottom + groupPadding
+}
+
+// keep adds the node left where it is to the box spanning the kept nodes.
+func (s *layoutScope) keep(id string, kept layoutBox, hasKept bool) layoutBox {
+	node := s.byID[id]
+
+	box := layoutBox{x: node.Position.X, y: node.Position.Y, width: node.Width, height: node.Height}
+	if box.width == 0 {
+		box.width = nodeWidth
+	}
+	if box.height == 0 {
+		box.height = nodeHeight
+	}
+
+	if !hasKept {
+		return box
+	}
+
+	left, top := math.Min(kept.x, box.x), math.Min(kept.y, box.y)
+	right := math.Max(kept.x+kept.width, box.x+box.width)
+	bottom := math.Max(kept.y+kept.height, box.y+box.height)
+
+	return layoutBox{x: left, y: top, width: right - left, height: bottom - top}
+}
+
+// childOf returns the child of group that holds node, or node itself when it
+// is a child, and an empty string when node is not inside group.
+func (s *layoutScope) childOf(group string, node string) string {
+	for id := node; id != ""; {
+		n, ok := s.byID[id]
+
+		if !ok {
+			return ""
+		}
+
+		parent := n.ParentNode
+		if _, ok := s.byID[parent]; !ok {
+			parent = ""
+		}
+
+		if parent == group {
+			return id
+		}
+
+		id = parent
+	}
+
+	return ""
+}
+
+// apply writes the positions found to the nodes inside group, which starts at
+// origin. Kept nodes only get their absolute position.
+func (s *layoutScope) apply(group string, origin transport.PositionDto) {
+	for _, id := range s.children[group] {
+		node, box := s.byID[id], s.boxes[id]
+
+		if s.kept[id] {
+			node.PositionAbsolute = transport.PositionDto{X: origin.X + node.Position.X, Y: origin.Y + node.Position.Y}
+			s.apply(id, node.PositionAbsolute)
+			continue
+		}
+
+		node.Position = transport.PositionDto{X: box.x, Y: box.y}
+		node.PositionAbsolute = transport.PositionDto{X: origin.X + box.x, Y: origin.Y + box.y}
+		node.Width = box.width
+		node.Height = box.height
+
+		if len(s.children[id]) > 0 {
+			node.Style = json.RawMessage(fmt.Sprintf(`{"width":%g,"height":%g}`, node.Width, node.Height))
+			s.apply(id, node.PositionAbsolute)
+		}
+	}
+}
+
+// layerNodes puts every node one layer past the furthest node with an edge to
+// it. Edges closing a loop are left out, and nodes keep the order they were
+// declared in within their layer.
+func layerNodes(ids []string, edges []*transport.EdgeDto) [][]string {
+	included := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		included[id] = true
+	}
+
+	outgoing := map[string][]string{}
+	incoming := map[string]int{}
+	for _, edge := range edges {
+		if included[edge.Source] && included[edge.Target] && edge.Source != edge.Target {
+			outgoing[edge.Source] = append(outgoing[edge.Source], edge.Target)
+		}
+	}
+
+	for _, targets := range outgoing {
+		for _, target := range targets {
+			incoming[target]++
+		}
+	}
+
+	// A depth-first walk from the nodes without incoming edges, then from any
+	// node left over, finds the edges closing a loop.
+	starts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if incoming[id] == 0 {
+			starts = append(starts, id)
+		}
+	}
+	starts = append(starts, ids...)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	forward := map[string][]string{}
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		for _, target := range outgoing[id] {
+			switch state[target] {
+			case unvisited:
+				forward[id] = append(forward[id], target)
+				visit(target)
+			case done:
+				forward[id] = append(forward[id], target)
+			}
+		}
+		state[id] = done
+	}
+
+	for _, id := range starts {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+
+	pending := map[string]int{}
+	for _, targets := range forward {
+		for _, target := range targets {
+			pending[target]++
+		}
+	}
+
+	depth := map[string]int{}
+	queue := []string{}
+	for _, id := range ids {
+		if pending[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, target := range forward[id] {
+			if depth[id]+1 > depth[target] {
+				depth[target] = depth[id] + 1
+			}
+
+			pending[target]--
+			if pending[target] == 0 {
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	var layers [][]string
+	for _, id := range ids {
+		for len(layers) <= depth[id] {
+			layers = append(layers, nil)
+		}
+		layers[depth[id]] = append(layers[depth[id]], id)
+	}
+
+	return layers
+}
@@ -0,0 +1,736 @@
+package importer
+
+import (
+	"encoding/json"
+	"flowChart/domain"
+	"flowChart/transport"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// mermaidShape is a pair of brackets around a node label and the React Flow
+// type the node gets. Shapes without a matching type become default nodes; the
+// input node is chosen from the links instead, as it marks the root.
+type mermaidShape struct {
+	open     string
+	close    []string
+	nodeType string
+}
+
+// mermaidShapes is ordered so that longer brackets are tried first.
+var mermaidShapes = []mermaidShape{
+	{`(((`, []string{`)))`}, "output"},
+	{`([`, []string{`])`}, "default"},
+	{`((`, []string{`))`}, "output"},
+	{`[[`, []string{`]]`}, "default"},
+	{`[(`, []string{`)]`}, "default"},
+	{`[/`, []string{`/]`, `\]`}, "default"},
+	{`[\`, []string{`\]`, `/]`}, "default"},
+	{`{{`, []string{`}}`}, "default"},
+	{`(`, []string{`)`}, "default"},
+	{`[`, []string{`]`}, "default"},
+	{`{`, []string{`}`}, "decision"},
+	{`>`, []string{`]`}, "default"},
+}
+
+var (
+	mermaidHeader      = regexp.MustCompile(`^(flowchart|graph)(\s+(TB|TD|BT|RL|LR))?$`)
+	mermaidLabeledLink = regexp.MustCompile(`^(<?)(--|==|-\.)\s+(.*?)\s*(-{2,}[>ox]|-{3,}|={2,}[>ox]|={3,}|\.-+[>ox]|\.-+)`)
+	mermaidLink        = regexp.MustCompile(`^(<?)(-{2,}[>ox]?|={2,}[>ox]?|-\.+-[>ox]?|~~~)`)
+	mermaidSubgraph    = regexp.MustCompile(`^([\p{L}\p{N}_-]+)\s*\[(.*)\]$`)
+	mermaidEntity      = regexp.MustCompile(`#(\w+);`)
+	mermaidBreak       = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// mermaidIgnored are the statements that only change how Mermaid draws the
+// flowchart.
+var mermaidIgnored = map[string]bool{
+	"direction": true,
+	"classDef":  true,
+	"class":     true,
+	"style":     true,
+	"linkStyle": true,
+	"click":     true,
+	"accTitle":  true,
+	"accDescr":  true,
+}
+
+type mermaidLinkStyle struct {
+	label  string
+	start  bool
+	end    bool
+	thick  bool
+	dotted bool
+	hidden bool
+}
+
+type mermaidSubgraphScope struct {
+	id      string
+	members []string
+}
+
+type mermaidParser struct {
+	line      int
+	direction string
+	header    bool
+	nodes     []*transport.NodeDto[transport.UnstructuredDataDto]
+	byID      map[string]*transport.NodeDto[transport.UnstructuredDataDto]
+	edges     []*transport.EdgeDto
+	edgeIDs   map[string]bool
+	open      []*mermaidSubgraphScope
+	parents   map[string]string
+	subgraphs int
+}
+
+// Mermaid reads a Mermaid flowchart. Node shapes give the node types, edge
+// labels and arrow heads are kept, and subgraphs become group nodes holding
+// the nodes declared inside them. Mermaid sources have no positions, so the
+// nodes are laid out in layers following the direction of the flowchart.
+//
+// When every node has a link coming in, the first one declared becomes the
+// input node, so the root of a flowchart made of loops can still be found.
+func Mermaid(source string) (*transport.FlowChartDto[transport.UnstructuredDataDto], error) {
+	p := &mermaidParser{
+		direction: "TB",
+		byID:      map[string]*transport.NodeDto[transport.UnstructuredDataDto]{},
+		edgeIDs:   map[string]bool{},
+		parents:   map[string]string{},
+	}
+
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	title, first := mermaidFrontMatter(lines)
+
+	for i := first; i < len(lines); i++ {
+		p.line = i + 1
+		line := strings.TrimSpace(lines[i])
+
+		if strings.HasPrefix(line, "%%") {
+			continue
+		}
+
+		for _, statement := range splitStatements(line) {
+			if err := p.statement(statement); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !p.header {
+		return nil, p.errorf("expected a flowchart or graph declaration")
+	}
+
+	if len(p.open) > 0 {
+		return nil, p.errorf("subgraph %q is not closed with end", p.open[len(p.open)-1].id)
+	}
+
+	for _, node := range p.nodes {
+		node.ParentNode = p.parents[node.Id]
+	}
+
+	p.linkSubgraphs()
+	p.markInput()
+
+	flowChart := &transport.FlowChartDto[transport.UnstructuredDataDto]{
+		Title: title,
+		Nodes: p.nodes,
+		Edges: p.edges,
+	}
+
+	layout(flowChart, p.direction, nil)
+
+	return flowChart, nil
+}
+
+// mermaidFrontMatter reads the title out of the YAML block that may open the
+// source, and returns the line the diagram starts at.
+func mermaidFrontMatter(lines []string) (string, int) {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return "", 0
+	}
+
+	title := ""
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if line == "---" {
+			return title, i + 1
+		}
+
+		if value, ok := strings.CutPrefix(line, "title:"); ok {
+			title = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+
+	return "", 0
+}
+
+// splitStatements splits a line on the semicolons ending statements, leaving
+// the ones inside quoted labels and entity codes such as #quot; alone.
+func splitStatements(line string) []string {
+	var statements []string
+
+	quoted := false
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if quoted || mermaidEntity.MatchString(entityBefore(line[:i+1])) {
+				continue
+			}
+
+			statements = append(statements, line[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(statements, line[start:])
+}
+
+func entityBefore(text string) string {
+	if i := strings.LastIndexByte(text, '#'); i >= 0 {
+		return text[i:]
+	}
+	return ""
+}
+
+func (p *mermaidParser) errorf(format string, args ...any) error {
+	return &SyntaxError{Format: MermaidFormat, Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *mermaidParser) statement(statement string) error {
+	statement = strings.TrimSpace(statement)
+
+	if statement == "" {
+		return nil
+	}
+
+	if !p.header {
+		match := mermaidHeader.FindStringSubmatch(statement)
+
+		if match == nil {
+			return p.errorf("expected a flowchart or graph declaration, found %q", statement)
+		}
+
+		p.header = true
+		if match[3] != "" {
+			p.direction = match[3]
+		}
+		return nil
+	}
+
+	keyword, rest, _ := strings.Cut(statement, " ")
+
+	switch {
+	case keyword == "subgraph":
+		return p.subgraph(strings.TrimSpace(rest))
+	case statement == "end":
+		return p.end()
+	case mermaidIgnored[keyword] || strings.HasPrefix(keyword, "accTitle:") || strings.HasPrefix(keyword, "accDescr:"):
+		return nil
+	default:
+		return p.chain(&mermaidScanner{text: statement})
+	}
+}
+
+func (p *mermaidParser) subgraph(declaration string) error {
+	var id, title string
+
+	if match := mermaidSubgraph.FindStringSubmatch(declaration); match != nil {
+		id, title = match[1], mermaidLabel(match[2])
+	} else if scanner := (&mermaidScanner{text: declaration}); scanner.identifier() == declaration && declaration != "" {
+		id, title = declaration, declaration
+	} else {
+		p.subgraphs++
+		id, title = fmt.Sprintf("subgraph_%d", p.subgraphs), mermaidLabel(declaration)
+	}
+
+	group := p.node(id)
+	group.Type = "group"
+	group.Data = labelData(title)
+
+	p.open = append(p.open, &mermaidSubgraphScope{id: id})
+	return nil
+}
+
+// end closes the innermost subgraph, which takes every node mentioned inside it
+// that no inner subgraph took already.
+func (p *mermaidParser) end() error {
+	if len(p.open) == 0 {
+		return p.errorf("end without a subgraph")
+	}
+
+	scope := p.open[len(p.open)-1]
+	p.open = p.open[:len(p.open)-1]
+
+	for _, member := range scope.members {
+		if _, ok := p.parents[member]; ok || member == scope.id {
+			continue
+		}
+		p.parents[member] = scope.id
+	}
+
+	return nil
+}
+
+// node returns the node with the given id, declaring it the first time it is
+// mentioned.
+func (p *mermaidParser) node(id string) *transport.NodeDto[transport.UnstructuredDataDto] {
+	if len(p.open) > 0 {
+		scope := p.open[len(p.open)-1]
+		scope.members = append(scope.members, id)
+	}
+
+	if node, ok := p.byID[id]; ok {
+		return node
+	}
+
+	node := &transport.NodeDto[transport.UnstructuredDataDto]{Id: id, Data: labelData(id), Type: "default"}
+	p.nodes = append(p.nodes, node)
+	p.byID[id] = node
+	return node
+}
+
+// chain reads nodes joined by links, such as A & B --> C -- yes --> D, and adds
+// an edge from every node before each link to every node after it.
+func (p *mermaidParser) chain(s *mermaidScanner) error {
+	var groups [][]string
+	var links []mermaidLinkStyle
+
+	for {
+		ids, err := p.nodeGroup(s)
+
+		if err != nil {
+			return err
+		}
+
+		groups = append(groups, ids)
+
+		s.skipSpace()
+		if s.done() {
+			break
+		}
+
+		link, ok := s.link()
+
+		if !ok {
+			return p.errorf("unexpected %q", s.rest())
+		}
+
+		links = append(links, link)
+	}
+
+	for i, link := range links {
+		for _, source := range groups[i] {
+			for _, target := range groups[i+1] {
+				p.edge(source, target, link)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *mermaidParser) nodeGroup(s *mermaidScanner) ([]string, error) {
+	var ids []string
+
+	for {
+		s.skipSpace()
+		id, err := p.nodeStatement(s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+
+		s.skipSpace()
+		if !s.consume("&") {
+			return ids, nil
+		}
+	}
+}
+
+func (p *mermaidParser) nodeStatement(s *mermaidScanner) (string, error) {
+	id := s.identifier()
+
+	if id == "" {
+		return "", p.errorf("expected a node id, found %q", s.rest())
+	}
+
+	if id == "end" {
+		return "", p.errorf("end cannot be used as a node id")
+	}
+
+	node := p.node(id)
+
+	for _, shape := range mermaidShapes {
+		if !s.consume(shape.open) {
+			continue
+		}
+
+		label, err := p.shapeLabel(s, shape)
+
+		if err != nil {
+			return "", err
+		}
+
+		node.Data = labelData(label)
+		if node.Type != "group" {
+			node.Type = shape.nodeType
+		}
+		break
+	}
+
+	if s.consume(":::") {
+		s.identifier()
+	}
+
+	return id, nil
+}
+
+func (p *mermaidParser) shapeLabel(s *mermaidScanner, shape mermaidShape) (string, error) {
+	rest := s.rest()
+
+	if strings.HasPrefix(rest, `"`) {
+		end := strings.IndexByte(rest[1:], '"')
+
+		if end < 0 {
+			return "", p.errorf("unclosed quote in %q", rest)
+		}
+
+		s.pos += end + 2
+		s.skipSpace()
+
+		for _, close := range shape.close {
+			if s.consume(close) {
+				return mermaidText(rest[1 : end+1]), nil
+			}
+		}
+
+		return "", p.errorf("expected %q after %q", shape.close[0], rest[:end+2])
+	}
+
+	best, closing := -1, ""
+	for _, close := range shape.close {
+		if i := strings.Index(rest, close); i >= 0 && (best < 0 || i < best) {
+			best, closing = i, close
+		}
+	}
+
+	if best < 0 {
+		return "", p.errorf("expected %q to close %q", shape.close[0], shape.open+rest)
+	}
+
+	s.pos += best + len(closing)
+	return mermaidText(strings.TrimSpace(rest[:best])), nil
+}
+
+// edge adds an edge drawn with the given link.
+func (p *mermaidParser) edge(source string, target string, link mermaidLinkStyle) {
+	edge := &transport.EdgeDto{Source: source, Target: target, Label: link.label, Hidden: link.hidden}
+
+	if link.end {
+		edge.MarkerEnd = json.RawMessage(`{"type":"arrowclosed"}`)
+	}
+
+	if link.start {
+		edge.MarkerStart = json.RawMessage(`{"type":"arrowclosed"}`)
+	}
+
+	switch {
+	case link.thick:
+		edge.Style = json.RawMessage(`{"strokeWidth":2}`)
+	case link.dotted:
+		edge.Style = json.RawMessage(`{"strokeDasharray":"5 5"}`)
+	}
+
+	p.addEdge(edge)
+}
+
+// addEdge gives the edge the id React Flow would give it, numbered when the
+// same nodes are linked more than once.
+func (p *mermaidParser) addEdge(edge *transport.EdgeDto) {
+	id := domain.EdgeID(edge.Source, edge.Target)
+	for candidate, i := id, 1; ; i++ {
+		if !p.edgeIDs[candidate] {
+			id = candidate
+			break
+		}
+		candidate = fmt.Sprintf("%s-%d", id, i)
+	}
+	p.edgeIDs[id] = true
+
+	edge.Id = id
+	p.edges = append(p.edges, edge)
+}
+
+// linkSubgraphs replaces the links Mermaid draws to and from the border of a
+// subgraph, since a React Flow group only holds nodes. A link leaving a
+// subgraph starts at each node the flow leaves it by, and a link entering one
+// ends at each node the flow enters it by.
+func (p *mermaidParser) linkSubgraphs() {
+	members := map[string][]string{}
+	for _, node := range p.nodes {
+		if parent := p.parents[node.Id]; parent != "" {
+			members[parent] = append(members[parent], node.Id)
+		}
+	}
+
+	edges := p.edges
+	p.edges = nil
+	p.edgeIDs = map[string]bool{}
+
+	for _, edge := range edges {
+		sources := p.subgraphBoundary(edge.Source, members, edges, false)
+		targets := p.subgraphBoundary(edge.Target, members, edges, true)
+
+		for _, source := range sources {
+			for _, target := range targets {
+				linked := *edge
+				linked.Source, linked.Target = source, target
+				p.addEdge(&linked)
+			}
+		}
+	}
+}
+
+// subgraphBoundary returns the nodes the flow enters a subgraph by, the ones
+// with no link from another node of the subgraph, or leaves it by, the ones
+// with no link to another. Nested subgraphs are crossed the same way, and any
+// other node is its own boundary.
+func (p *mermaidParser) subgraphBoundary(id string, members map[string][]string, edges []*transport.EdgeDto, entering bool) []string {
+	inside := members[id]
+
+	if len(inside) == 0 {
+		return []string{id}
+	}
+
+	linked := map[string]bool{}
+	for _, edge := range edges {
+		source, target := p.memberOf(id, edge.Source), p.memberOf(id, edge.Target)
+
+		if source == "" || target == "" || source == target {
+			continue
+		}
+
+		if entering {
+			linked[target] = true
+		} else {
+			linked[source] = true
+		}
+	}
+
+	var boundary []string
+	for _, m := range inside {
+		if !linked[m] {
+			boundary = append(boundary, m)
+		}
+	}
+
+	// A subgraph holding a single loop is entered and left by its first node.
+	if len(boundary) == 0 {
+		boundary = inside[:1]
+	}
+
+	var nodes []string
+	for _, m := range boundary {
+		nodes = append(nodes, p.subgraphBoundary(m, members, edges, entering)...)
+	}
+
+	return nodes
+}
+
+// memberOf returns the node of the subgraph that holds the given node, either
+// the node itself or the nested subgraph it is in, or "" when it is outside.
+func (p *mermaidParser) memberOf(subgraph string, id string) string {
+	for seen := map[string]bool{}; !seen[id]; {
+		seen[id] = true
+
+		parent := p.parents[id]
+		if parent == subgraph {
+			return id
+		}
+
+		if parent == "" {
+			break
+		}
+		id = parent
+	}
+
+	return ""
+}
+
+// markInput makes the first node declared the input node when every node has
+// a link coming in. Group nodes are left out, as they are never linked once
+// the links to subgraphs are replaced.
+func (p *mermaidParser) markInput() {
+	linked := map[string]bool{}
+	for _, edge := range p.edges {
+		if edge.Source != edge.Target {
+			linked[edge.Target] = true
+		}
+	}
+
+	var first *transport.NodeDto[transport.UnstructuredDataDto]
+	for _, node := range p.nodes {
+		if node.Type == "group" {
+			continue
+		}
+
+		if !linked[node.Id] {
+			return
+		}
+
+		if first == nil {
+			first = node
+		}
+	}
+
+	if first != nil {
+		first.Type = domain.InputNodeType
+	}
+}
+
+// mermaidLabel reads a label that may be quoted.
+func mermaidLabel(text string) string {
+	text = strings.TrimSpace(text)
+
+	if len(text) >= 2 && strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) {
+		text = text[1 : len(text)-1]
+	}
+
+	return mermaidText(text)
+}
+
+// mermaidText decodes the entity codes and line breaks Mermaid labels are
+// written with.
+func mermaidText(text string) string {
+	text = mermaidBreak.ReplaceAllString(text, "\n")
+
+	return mermaidEntity.ReplaceAllStringFunc(text, func(entity string) string {
+		name := entity[1 : len(entity)-1]
+
+		switch name {
+		case "quot":
+			return `"`
+		case "amp":
+			return "&"
+		case "lt":
+			return "<"
+		case "gt":
+			return ">"
+		}
+
+		if code, err := strconv.Atoi(name); err == nil {
+			return string(rune(code))
+		}
+
+		return entity
+	})
+}
+
+type mermaidScanner struct {
+	text string
+	pos  int
+}
+
+func (s *mermaidScanner) rest() string {
+	return s.text[s.pos:]
+}
+
+func (s *mermaidScanner) done() bool {
+	return s.pos >= len(s.text)
+}
+
+func (s *mermaidScanner) skipSpace() {
+	for !s.done() && (s.text[s.pos] == ' ' || s.text[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+func (s *mermaidScanner) consume(prefix string) bool {
+	if strings.HasPrefix(s.rest(), prefix) {
+		s.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// identifier reads a node id. A dash belongs to the id only when it is not the
+// start of a link.
+func (s *mermaidScanner) identifier() string {
+	start := s.pos
+
+	for i, r := range s.rest() {
+		if isIdentifierRune(r) {
+			continue
+		}
+
+		if r == '-' && i+1 < len(s.rest()) && isIdentifierRune(rune(s.rest()[i+1])) {
+			continue
+		}
+
+		s.pos = start + i
+		return s.text[start:s.pos]
+	}
+
+	s.pos = len(s.text)
+	return s.text[start:]
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// link reads a link and its label, in either the A -- label --> B or the
+// A -->|label| B form.
+func (s *mermaidScanner) link() (mermaidLinkStyle, bool) {
+	if match := mermaidLabeledLink.FindStringSubmatch(s.rest()); match != nil {
+		s.pos += len(match[0])
+		return linkStyle(match[1], match[2]+match[4], mermaidLabel(match[3])), true
+	}
+
+	match := mermaidLink.FindStringSubmatch(s.rest())
+
+	if match == nil {
+		return mermaidLinkStyle{}, false
+	}
+
+	arrow := match[2]
+
+	// A circle or cross head followed by a letter is the start of the next
+	// node id, as in A---oak.
+	if head := arrow[len(arrow)-1]; (head == 'o' || head == 'x') && s.pos+len(match[0]) < len(s.text) &&
+		isIdentifierRune(rune(s.text[s.pos+len(match[0])])) {
+		arrow = arrow[:len(arrow)-1]
+	}
+
+	s.pos += len(match[1]) + len(arrow)
+
+	label := ""
+	s.skipSpace()
+	if strings.HasPrefix(s.rest(), "|") {
+		if end := strings.IndexByte(s.rest()[1:], '|'); end >= 0 {
+			label = mermaidLabel(s.rest()[1 : end+1])
+			s.pos += end + 2
+		}
+	}
+
+	return linkStyle(match[1], arrow, label), true
+}
+
+func linkStyle(start string, arrow string, label string) mermaidLinkStyle {
+	return mermaidLinkStyle{
+		label:  label,
+		start:  start == "<",
+		end:    strings.HasSuffix(arrow, ">"),
+		thick:  strings.Contains(arrow, "="),
+		dotted: strings.Contains(arrow, "."),
+		hidden: arrow == "~~~",
+	}
+}
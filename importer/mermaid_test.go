@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"encoding/json"
+	"flowChart/domain"
+	"flowChart/exporter"
+	"flowChart/transport"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func toDomain(t *testing.T, flowChart *transport.FlowChartDto[transport.UnstructuredDataDto]) *domain.FlowChart[transport.UnstructuredDataDto] {
+	t.Helper()
+
+	converted, err := transport.ToDomain(flowChart, func(data transport.UnstructuredDataDto) transport.UnstructuredDataDto { return data })
+	if err != nil {
+		t.Fatalf("ToDomain() error = %v", err)
+	}
+
+	return converted
+}
+
+func nodeTypes(flowChart *transport.FlowChartDto[transport.UnstructuredDataDto]) map[string]string {
+	types := map[string]string{}
+	for _, node := range flowChart.Nodes {
+		types[node.Id] = node.Type
+	}
+	return types
+}
+
+func edgeEnds(flowChart *transport.FlowChartDto[transport.UnstructuredDataDto]) []string {
+	var ends []string
+	for _, edge := range flowChart.Edges {
+		ends = append(ends, edge.Source+">"+edge.Target)
+	}
+	return ends
+}
+
+func TestMermaidShapes(t *testing.T) {
+	flowChart, err := Mermaid("flowchart LR\n  a([Stadium]) --> b{Choice} --> c((Circle)) --> d[Box]")
+	if err != nil {
+		t.Fatalf("Mermaid() error = %v", err)
+	}
+
+	want := map[string]string{"a": "default", "b": "decision", "c": "output", "d": "default"}
+	if got := nodeTypes(flowChart); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("node types = %v, want %v", got, want)
+	}
+
+	if root := toDomain(t, flowChart).Node.NodeID; root != "a" {
+		t.Errorf("root = %q, want %q", root, "a")
+	}
+}
+
+func TestMermaidLoopMarksFirstNodeAsInput(t *testing.T) {
+	flowChart, err := Mermaid("flowchart TD\nA[Start] --> B{Ok?}; B -- yes --> C([Done]); B -->|no| A")
+	if err != nil {
+		t.Fatalf("Mermaid() error = %v", err)
+	}
+
+	want := map[string]string{"A": domain.InputNodeType, "B": "decision", "C": "default"}
+	if got := nodeTypes(flowChart); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("node types = %v, want %v", got, want)
+	}
+
+	if root := toDomain(t, flowChart).Node.NodeID; root != "A" {
+		t.Errorf("root = %q, want %q", root, "A")
+	}
+}
+
+func TestMermaidLinkedSubgraphs(t *testing.T) {
+	flowChart, err := Mermaid(strings.Join([]string{
+		"flowchart TD",
+		"subgraph one",
+		"  a1 --> a2",
+		"end",
+		"subgraph two",
+		"  b1 --> b2",
+		"end",
+		"one --> two",
+	}, "\n"))
+	if err != nil {
+		t.Fatalf("Mermaid() error = %v", err)
+	}
+
+	want := []string{"a1>a2", "b1>b2", "a2>b1"}
+	if got := edgeEnds(flowChart); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+
+	if got := nodeTypes(flowChart); got["one"] != "group" || got["two"] != "group" || got["a1"] != "default" {
+		t.Errorf("node types = %v, want groups one and two holding default nodes", got)
+	}
+
+	if root := toDomain(t, flowChart).Node.NodeID; root != "a1" {
+		t.Errorf("root = %q, want %q", root, "a1")
+	}
+}
+
+func TestMermaidNestedSubgraphLink(t *testing.T) {
+	flowChart, err := Mermaid(strings.Join([]string{
+		"flowchart LR",
+		"start --> outer",
+		"subgraph outer",
+		"  subgraph inner",
+		"    x --> y",
+		"  end",
+		"  y --> z",
+		"end",
+	}, "\n"))
+	if err != nil {
+		t.Fatalf("Mermaid() error = %v", err)
+	}
+
+	want := []string{"start>x", "x>y", "y>z"}
+	if got := edgeEnds(flowChart); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+
+	toDomain(t, flowChart)
+}
+
+func TestMermaidRoundTrip(t *testing.T) {
+	source := strings.Join([]string{
+		"flowchart TD",
+		"start([Begin]) --> check{Ok?}",
+		"subgraph work [Work]",
+		"  w1 --> w2",
+		"end",
+		"check -->|yes| w1",
+		"w2 --> done((Done))",
+	}, "\n")
+
+	imported, err := Mermaid(source)
+	if err != nil {
+		t.Fatalf("Mermaid() error = %v", err)
+	}
+
+	exported := exporter.Mermaid(toDomain(t, imported))
+
+	reimported, err := Mermaid(exported)
+	if err != nil {
+		t.Fatalf("Mermaid() of the export error = %v\n%s", err, exported)
+	}
+
+	parents := map[string]string{}
+	for _, node := range reimported.Nodes {
+		parents[node.Id] = node.ParentNode
+	}
+
+	if parents["w1"] != "work" || parents["w2"] != "work" {
+		t.Errorf("parents = %v, want w1 and w2 inside work\n%s", parents, exported)
+	}
+
+	if got, want := fmt.Sprint(nodeTypes(reimported)), fmt.Sprint(nodeTypes(imported)); got != want {
+		t.Errorf("node types = %v, want %v", got, want)
+	}
+
+	toDomain(t, reimported)
+}
+
+func TestLayoutLargeGroup(t *testing.T) {
+	lines := []string{"flowchart LR", "subgraph wide", "  hub"}
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("  hub --> n%d", i))
+	}
+	lines = append(lines, "end")
+
+	flowChart, err := Mermaid(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatalf("Mermaid() error = %v", err)
+	}
+
+	for _, node := range flowChart.Nodes {
+		if node.Width <= 0 || node.Height <= 0 {
+			t.Errorf("node %q size = %gx%g, want positive", node.Id, node.Width, node.Height)
+		}
+
+		if node.Id != "wide" {
+			continue
+		}
+
+		if node.Height < 200*nodeHeight {
+			t.Errorf("group height = %g, want room for 200 nodes", node.Height)
+		}
+
+		var style struct{ Width, Height float64 }
+		if err := json.Unmarshal(node.Style, &style); err != nil || style.Width != node.Width || style.Height != node.Height {
+			t.Errorf("group style = %s, want the size %gx%g", node.Style, node.Width, node.Height)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package importer
+
+import (
+	"flowChart/transport"
+	"regexp"
+	"strconv"
+)
+
+var yamlLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+// Yaml reads a flowchart kept as YAML. Nodes without a position are laid out
+// from top to bottom, below the nodes that have one.
+func Yaml(source []byte) (*transport.FlowChartDto[transport.UnstructuredDataDto], error) {
+	flowChart, positioned, err := transport.DecodeYAML[transport.UnstructuredDataDto](source)
+
+	if err != nil {
+		return nil, yamlError(err)
+	}
+
+	if len(positioned) < len(flowChart.Nodes) {
+		layout(flowChart, "TB", positioned)
+	}
+
+	return flowChart, nil
+}
+
+// yamlError points to the first line the YAML decoder complained about.
+func yamlError(err error) error {
+	match := yamlLine.FindStringSubmatch(err.Error())
+
+	if match == nil {
+		return &SyntaxError{Format: YamlFormat, Message: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	return &SyntaxError{Format: YamlFormat, Line: line, Message: match[2]}
+}
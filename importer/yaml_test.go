@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"flowChart/transport"
+	"testing"
+)
+
+func TestYamlWithoutPositionsIsLaidOut(t *testing.T) {
+	source := `key: review
+title: Review
+nodes:
+  - id: a
+    type: input
+  - id: b
+edges:
+  - source: a
+    target: b
+`
+
+	flowChart, err := Import(YamlFormat, []byte(source))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if flowChart.Key != "review" || flowChart.Edges[0].Id == "" {
+		t.Errorf("key = %q and edge id = %q, want the key kept and an edge id given", flowChart.Key, flowChart.Edges[0].Id)
+	}
+
+	a, b := flowChart.Nodes[0], flowChart.Nodes[1]
+	if b.Position.Y <= a.Position.Y {
+		t.Errorf("b at %v is not below a at %v", b.Position, a.Position)
+	}
+}
+
+func TestYamlKeepsGivenPositions(t *testing.T) {
+	source := `key: review
+nodes:
+  - id: a
+    type: input
+    position: {x: 10, y: 20}
+    width: 200
+    height: 50
+  - id: box
+    position: {x: 0, y: 200}
+    width: 400
+    height: 300
+    style: {width: 400, height: 300}
+  - id: c
+    parentId: box
+    position: {x: 30, y: 40}
+  - id: d
+    parentId: box
+  - id: b
+edges:
+  - source: a
+    target: b
+  - source: b
+    target: c
+  - source: c
+    target: d
+`
+
+	flowChart, err := Import(YamlFormat, []byte(source))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	nodes := map[string]int{}
+	for i, node := range flowChart.Nodes {
+		nodes[node.Id] = i
+	}
+	node := func(id string) *transport.NodeDto[transport.UnstructuredDataDto] { return flowChart.Nodes[nodes[id]] }
+
+	if a := node("a"); a.Position != (transport.PositionDto{X: 10, Y: 20}) || a.Width != 200 || a.Height != 50 {
+		t.Errorf("a at %v sized %gx%g, want it where and as large as the file says", a.Position, a.Width, a.Height)
+	}
+
+	if box := node("box"); box.Width != 400 || string(box.Style) != `{"height":300,"width":400}` {
+		t.Errorf("box sized %gx%g with style %s, want the size and style the file gives", box.Width, box.Height, box.Style)
+	}
+
+	if c := node("c"); c.Position != (transport.PositionDto{X: 30, Y: 40}) || c.PositionAbsolute != (transport.PositionDto{X: 30, Y: 240}) {
+		t.Errorf("c at %v, %v absolute, want {30 40} inside box", c.Position, c.PositionAbsolute)
+	}
+
+	if b := node("b"); b.Position.Y < 500+layerGap {
+		t.Errorf("b at %v, want it below the nodes that have a position", b.Position)
+	}
+
+	if d := node("d"); d.ParentNode != "box" || d.Position.Y <= 40 || d.PositionAbsolute.Y != 200+d.Position.Y {
+		t.Errorf("d at %v in %q, want it below c inside box", d.Position, d.ParentNode)
+	}
+}
@@ -3,9 +3,18 @@ package main
 import (
 	"flowChart/server"
 	"flowChart/service"
+	"log"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := service.Migrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	application := service.Bootstrap()
 	server.RunHttpServer(":8000", application)
 }
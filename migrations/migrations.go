@@ -0,0 +1,357 @@
+// Package migrations keeps the numbered schema migrations of every supported
+// database inside the binary and applies them, recording each one in the
+// schema_migrations table.
+//
+// Migrations are files named NNNN_name.up.sql with a matching
+// NNNN_name.down.sql, one directory per database. A new migration takes the
+// next number; applied files must never be edited.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+var ErrMissingDown = errors.New("migration has no down file")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations of one database. lock is run at the start
+// of every migration transaction, and before schema_migrations is created, so
+// two processes starting together do not apply the same migration twice.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	lock       string
+}
+
+func NewPostgresMigrator(db *sqlx.DB) (*Migrator, error) {
+	return newMigrator(db, "postgres", "SELECT pg_advisory_xact_lock(7231547)")
+}
+
+// NewSqliteMigrator expects a connection opened with _txlock=immediate, which
+// already serializes the migration transactions.
+func NewSqliteMigrator(db *sqlx.DB) (*Migrator, error) {
+	return newMigrator(db, "sqlite", "")
+}
+
+func newMigrator(db *sqlx.DB, dir string, lock string) (*Migrator, error) {
+	sub, err := fs.Sub(files, dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := Load(sub)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations, lock: lock}, nil
+}
+
+// Load reads the migrations of a directory, sorted by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for _, file := range names {
+		base, direction, ok := cutDirection(file)
+
+		if !ok {
+			return nil, fmt.Errorf("migration %s must end in .up.sql or .down.sql", file)
+		}
+
+		number, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s must start with a positive version number", file)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, name)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func cutDirection(file string) (base string, direction string, ok bool) {
+	file = path.Base(file)
+
+	if base, ok := strings.CutSuffix(file, ".up.sql"); ok {
+		return base, "up", true
+	}
+
+	if base, ok := strings.CutSuffix(file, ".down.sql"); ok {
+		return base, "down", true
+	}
+
+	return "", "", false
+}
+
+// createTable creates schema_migrations while holding the migration lock, so
+// two processes starting on an empty database do not both try to create it.
+func (m *Migrator) createTable(ctx context.Context) (err error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error beginning a transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if err := m.takeLock(ctx, tx); err != nil {
+		return err
+	}
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    int NOT NULL PRIMARY KEY,
+		name       varchar NOT NULL,
+		applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+// takeLock holds the migration lock until tx ends.
+func (m *Migrator) takeLock(ctx context.Context, tx *sqlx.Tx) error {
+	if m.lock == "" {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, m.lock); err != nil {
+		return fmt.Errorf("error locking schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context, tx *sqlx.Tx) (map[int]time.Time, error) {
+	rows := []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}{}
+
+	if err := tx.SelectContext(ctx, &rows, "SELECT version, applied_at FROM schema_migrations"); err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	return applied, nil
+}
+
+// step runs fn in a transaction holding the migration lock, with the versions
+// already applied.
+func (m *Migrator) step(ctx context.Context, fn func(tx *sqlx.Tx, applied map[int]time.Time) (bool, error)) (done bool, err error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return false, fmt.Errorf("error beginning a transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if err := m.takeLock(ctx, tx); err != nil {
+		return false, err
+	}
+
+	applied, err := m.applied(ctx, tx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return fn(tx, applied)
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the versions it applied.
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var versions []int
+
+	for _, migration := range m.migrations {
+		ran, err := m.step(ctx, func(tx *sqlx.Tx, applied map[int]time.Time) (bool, error) {
+			if _, ok := applied[migration.Version]; ok {
+				return false, nil
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return false, fmt.Errorf("error applying migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			query := tx.Rebind("INSERT into schema_migrations (version, name) VALUES (?, ?)")
+
+			if _, err := tx.ExecContext(ctx, query, migration.Version, migration.Name); err != nil {
+				return false, fmt.Errorf("error recording migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			return true, nil
+		})
+
+		if err != nil {
+			return versions, err
+		}
+
+		if ran {
+			versions = append(versions, migration.Version)
+		}
+	}
+
+	return versions, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the versions it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var versions []int
+
+	for i := len(m.migrations) - 1; i >= 0 && len(versions) < steps; i-- {
+		migration := m.migrations[i]
+
+		ran, err := m.step(ctx, func(tx *sqlx.Tx, applied map[int]time.Time) (bool, error) {
+			if _, ok := applied[migration.Version]; !ok {
+				return false, nil
+			}
+
+			if migration.Down == "" {
+				return false, fmt.Errorf("%w: %d_%s", ErrMissingDown, migration.Version, migration.Name)
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return false, fmt.Errorf("error reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			query := tx.Rebind("DELETE FROM schema_migrations WHERE version = ?")
+
+			if _, err := tx.ExecContext(ctx, query, migration.Version); err != nil {
+				return false, fmt.Errorf("error recording migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			return true, nil
+		})
+
+		if err != nil {
+			return versions, err
+		}
+
+		if ran {
+			versions = append(versions, migration.Version)
+		}
+	}
+
+	return versions, nil
+}
+
+// Status lists every known migration and when it was applied, nil when it is
+// still pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+
+	_, err := m.step(ctx, func(tx *sqlx.Tx, applied map[int]time.Time) (bool, error) {
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		return false, nil
+	})
+
+	return statuses, err
+}
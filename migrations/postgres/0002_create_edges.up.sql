@@ -0,0 +1,20 @@
+CREATE TABLE edge (
+    id            uuid DEFAULT uuid_generate_v4 (),
+    created_at    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    internal_id   varchar NOT NULL,
+    source        varchar NOT NULL,
+    target        varchar NOT NULL,
+    source_handle varchar,
+    target_handle varchar,
+    label         varchar NOT NULL DEFAULT '',
+    type          varchar(30) NOT NULL DEFAULT '',
+    animated      boolean,
+    style         json,
+    data          json,
+    sort_order    int NOT NULL DEFAULT 0,
+    flowchart_id  uuid NOT NULL,
+    CONSTRAINT    edge_flowchart_fk FOREIGN KEY (flowchart_id) REFERENCES flowchart(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT    edge_pk PRIMARY KEY (id),
+    CONSTRAINT    edge_internal_id_uq UNIQUE (flowchart_id, internal_id)
+);
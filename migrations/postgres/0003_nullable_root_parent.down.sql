@@ -0,0 +1 @@
+ALTER TABLE node ALTER COLUMN parent_id SET DEFAULT 0;
@@ -0,0 +1,3 @@
+-- The root is found from the graph, so a node without a parent keeps NULL
+-- instead of pointing to node 0.
+ALTER TABLE node ALTER COLUMN parent_id DROP DEFAULT;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS flowchart_version_immutable ON flowchart_version;
+DROP FUNCTION IF EXISTS forbid_flowchart_version_update();
+DROP TABLE IF EXISTS flowchart_version;
+
+ALTER TABLE flowchart DROP COLUMN IF EXISTS version;
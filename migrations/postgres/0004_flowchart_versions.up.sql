@@ -0,0 +1,24 @@
+ALTER TABLE flowchart ADD COLUMN version int NOT NULL DEFAULT 0;
+
+CREATE TABLE flowchart_version (
+    id           uuid DEFAULT uuid_generate_v4 (),
+    created_at   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version      int NOT NULL,
+    author       varchar NOT NULL DEFAULT '',
+    message      varchar NOT NULL DEFAULT '',
+    snapshot     JSONB NOT NULL,
+    flowchart_id uuid NOT NULL,
+    CONSTRAINT   flowchart_version_flowchart_fk FOREIGN KEY (flowchart_id) REFERENCES flowchart(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT   flowchart_version_pk PRIMARY KEY (id),
+    CONSTRAINT   flowchart_version_uq UNIQUE (flowchart_id, version)
+);
+
+CREATE OR REPLACE FUNCTION forbid_flowchart_version_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'flowchart versions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER flowchart_version_immutable
+    BEFORE UPDATE ON flowchart_version
+    FOR EACH ROW EXECUTE PROCEDURE forbid_flowchart_version_update();
@@ -0,0 +1,2 @@
+ALTER TABLE node DROP CONSTRAINT IF EXISTS node_internal_id_uq;
+ALTER TABLE node DROP COLUMN IF EXISTS sort_order;
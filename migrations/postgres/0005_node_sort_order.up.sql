@@ -0,0 +1,4 @@
+-- Nodes are written back in the order they were sent, and matched to the
+-- stored rows by their id.
+ALTER TABLE node ADD COLUMN sort_order int NOT NULL DEFAULT 0;
+ALTER TABLE node ADD CONSTRAINT node_internal_id_uq UNIQUE (flowchart_id, internal_id);
@@ -0,0 +1,3 @@
+-- Fails when a flowchart already holds a node id that is not a number.
+ALTER TABLE node ALTER COLUMN internal_id TYPE int USING internal_id::int;
+ALTER TABLE node ALTER COLUMN parent_id TYPE int USING parent_id::int;
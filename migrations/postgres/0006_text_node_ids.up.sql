@@ -0,0 +1,3 @@
+-- React Flow node ids are strings such as "dndnode_3" or UUIDs.
+ALTER TABLE node ALTER COLUMN internal_id TYPE varchar USING internal_id::text;
+ALTER TABLE node ALTER COLUMN parent_id TYPE varchar USING parent_id::text;
@@ -0,0 +1,12 @@
+ALTER TABLE edge DROP COLUMN IF EXISTS marker_end;
+ALTER TABLE edge DROP COLUMN IF EXISTS marker_start;
+ALTER TABLE edge DROP COLUMN IF EXISTS z_index;
+ALTER TABLE edge DROP COLUMN IF EXISTS hidden;
+
+ALTER TABLE node DROP COLUMN IF EXISTS style;
+ALTER TABLE node DROP COLUMN IF EXISTS hidden;
+ALTER TABLE node DROP COLUMN IF EXISTS z_index;
+ALTER TABLE node DROP COLUMN IF EXISTS extent;
+ALTER TABLE node DROP COLUMN IF EXISTS parent_node;
+
+ALTER TABLE flowchart DROP COLUMN IF EXISTS viewport;
@@ -0,0 +1,12 @@
+ALTER TABLE flowchart ADD COLUMN IF NOT EXISTS viewport JSONB;
+
+ALTER TABLE node ADD COLUMN IF NOT EXISTS parent_node varchar;
+ALTER TABLE node ADD COLUMN IF NOT EXISTS extent JSONB;
+ALTER TABLE node ADD COLUMN IF NOT EXISTS z_index int;
+ALTER TABLE node ADD COLUMN IF NOT EXISTS hidden boolean NOT NULL DEFAULT false;
+ALTER TABLE node ADD COLUMN IF NOT EXISTS style JSONB;
+
+ALTER TABLE edge ADD COLUMN IF NOT EXISTS hidden boolean NOT NULL DEFAULT false;
+ALTER TABLE edge ADD COLUMN IF NOT EXISTS z_index int;
+ALTER TABLE edge ADD COLUMN IF NOT EXISTS marker_start json;
+ALTER TABLE edge ADD COLUMN IF NOT EXISTS marker_end json;
@@ -0,0 +1,5 @@
+ALTER TABLE edge DROP COLUMN IF EXISTS extra;
+ALTER TABLE node DROP COLUMN IF EXISTS extra;
+
+ALTER TABLE node ALTER COLUMN height TYPE int USING round(height);
+ALTER TABLE node ALTER COLUMN width TYPE int USING round(width);
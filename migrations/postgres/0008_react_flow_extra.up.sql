@@ -0,0 +1,8 @@
+-- React Flow measures nodes in fractional pixels.
+ALTER TABLE node ALTER COLUMN width TYPE double precision;
+ALTER TABLE node ALTER COLUMN height TYPE double precision;
+
+-- Fields of nodes and edges without a column of their own, such as className
+-- or measured, kept to be sent back as they came.
+ALTER TABLE node ADD COLUMN extra JSONB;
+ALTER TABLE edge ADD COLUMN extra JSONB;
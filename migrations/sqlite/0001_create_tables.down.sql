@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS flowchart_version_immutable;
+DROP TABLE IF EXISTS flowchart_version;
+DROP TABLE IF EXISTS edge;
+DROP TABLE IF EXISTS node;
+DROP TABLE IF EXISTS flowchart;
@@ -0,0 +1,70 @@
+CREATE TABLE IF NOT EXISTS flowchart (
+    id           varchar NOT NULL DEFAULT (lower(hex(randomblob(16)))),
+    created_at   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    title        varchar NOT NULL,
+    key          varchar(50) UNIQUE NOT NULL,
+    version      int NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS node (
+    id           varchar NOT NULL DEFAULT (lower(hex(randomblob(16)))),
+    created_at   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    data         text NOT NULL CHECK (json_valid(data)),
+    position     text NOT NULL CHECK (json_valid(position)),
+    width        int NOT NULL,
+    height       int NOT NULL,
+    position_absolute text CHECK (position_absolute IS NULL OR json_valid(position_absolute)),
+    selected     boolean DEFAULT false,
+    dragging     boolean DEFAULT false,
+    internal_id  varchar NOT NULL,
+    parent_id    varchar,
+    flowchart_id varchar NOT NULL,
+    type         varchar(30) NOT NULL,
+    sort_order   int NOT NULL DEFAULT 0,
+    CONSTRAINT   flowchart_pk FOREIGN KEY (flowchart_id) REFERENCES flowchart(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT   node_pk PRIMARY KEY (id),
+    CONSTRAINT   node_internal_id_uq UNIQUE (flowchart_id, internal_id)
+);
+
+CREATE TABLE IF NOT EXISTS edge (
+    id            varchar NOT NULL DEFAULT (lower(hex(randomblob(16)))),
+    created_at    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    internal_id   varchar NOT NULL,
+    source        varchar NOT NULL,
+    target        varchar NOT NULL,
+    source_handle varchar,
+    target_handle varchar,
+    label         varchar NOT NULL DEFAULT '',
+    type          varchar(30) NOT NULL DEFAULT '',
+    animated      boolean,
+    style         text CHECK (style IS NULL OR json_valid(style)),
+    data          text CHECK (data IS NULL OR json_valid(data)),
+    sort_order    int NOT NULL DEFAULT 0,
+    flowchart_id  varchar NOT NULL,
+    CONSTRAINT    edge_flowchart_fk FOREIGN KEY (flowchart_id) REFERENCES flowchart(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT    edge_pk PRIMARY KEY (id),
+    CONSTRAINT    edge_internal_id_uq UNIQUE (flowchart_id, internal_id)
+);
+
+CREATE TABLE IF NOT EXISTS flowchart_version (
+    id           varchar NOT NULL DEFAULT (lower(hex(randomblob(16)))),
+    created_at   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version      int NOT NULL,
+    author       varchar NOT NULL DEFAULT '',
+    message      varchar NOT NULL DEFAULT '',
+    snapshot     text NOT NULL CHECK (json_valid(snapshot)),
+    flowchart_id varchar NOT NULL,
+    CONSTRAINT   flowchart_version_flowchart_fk FOREIGN KEY (flowchart_id) REFERENCES flowchart(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT   flowchart_version_pk PRIMARY KEY (id),
+    CONSTRAINT   flowchart_version_uq UNIQUE (flowchart_id, version)
+);
+
+CREATE TRIGGER IF NOT EXISTS flowchart_version_immutable
+    BEFORE UPDATE ON flowchart_version
+BEGIN
+    SELECT RAISE(ABORT, 'flowchart versions are immutable');
+END;
@@ -0,0 +1,12 @@
+ALTER TABLE edge DROP COLUMN marker_end;
+ALTER TABLE edge DROP COLUMN marker_start;
+ALTER TABLE edge DROP COLUMN z_index;
+ALTER TABLE edge DROP COLUMN hidden;
+
+ALTER TABLE node DROP COLUMN style;
+ALTER TABLE node DROP COLUMN hidden;
+ALTER TABLE node DROP COLUMN z_index;
+ALTER TABLE node DROP COLUMN extent;
+ALTER TABLE node DROP COLUMN parent_node;
+
+ALTER TABLE flowchart DROP COLUMN viewport;
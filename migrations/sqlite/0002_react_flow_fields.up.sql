@@ -0,0 +1,12 @@
+ALTER TABLE flowchart ADD COLUMN viewport text CHECK (viewport IS NULL OR json_valid(viewport));
+
+ALTER TABLE node ADD COLUMN parent_node varchar;
+ALTER TABLE node ADD COLUMN extent text CHECK (extent IS NULL OR json_valid(extent));
+ALTER TABLE node ADD COLUMN z_index int;
+ALTER TABLE node ADD COLUMN hidden boolean NOT NULL DEFAULT false;
+ALTER TABLE node ADD COLUMN style text CHECK (style IS NULL OR json_valid(style));
+
+ALTER TABLE edge ADD COLUMN hidden boolean NOT NULL DEFAULT false;
+ALTER TABLE edge ADD COLUMN z_index int;
+ALTER TABLE edge ADD COLUMN marker_start text CHECK (marker_start IS NULL OR json_valid(marker_start));
+ALTER TABLE edge ADD COLUMN marker_end text CHECK (marker_end IS NULL OR json_valid(marker_end));
@@ -0,0 +1,2 @@
+ALTER TABLE edge DROP COLUMN extra;
+ALTER TABLE node DROP COLUMN extra;
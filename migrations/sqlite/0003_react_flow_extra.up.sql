@@ -0,0 +1,5 @@
+-- Fields of nodes and edges without a column of their own, such as className
+-- or measured, kept to be sent back as they came. The int width and height
+-- columns already keep fractional sizes as they are.
+ALTER TABLE node ADD COLUMN extra text CHECK (extra IS NULL OR json_valid(extra));
+ALTER TABLE edge ADD COLUMN extra text CHECK (extra IS NULL OR json_valid(extra));
@@ -1,9 +1,17 @@
 package ports
 
 import (
+	"errors"
+	"flowChart/domain"
+	"flowChart/exporter"
 	"flowChart/handlers"
+	"flowChart/handlers/command"
+	"flowChart/importer"
 	"flowChart/transport"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,10 +21,103 @@ type Encode struct {
 	Err     string `json:"error"`
 }
 
+type ViolationEncode struct {
+	Rule    string `json:"rule"`
+	NodeID  string `json:"nodeId,omitempty"`
+	EdgeID  string `json:"edgeId,omitempty"`
+	Message string `json:"message"`
+}
+
+type ValidationEncode struct {
+	Success    bool              `json:"success"`
+	Err        string            `json:"error"`
+	Violations []ViolationEncode `json:"violations"`
+}
+
+func encodeValidationError(validationErr *domain.ValidationError) ValidationEncode {
+	violations := make([]ViolationEncode, 0, len(validationErr.Violations))
+	for _, v := range validationErr.Violations {
+		violations = append(violations, ViolationEncode{
+			Rule:    string(v.Rule),
+			NodeID:  v.NodeID,
+			EdgeID:  v.EdgeID,
+			Message: v.Message,
+		})
+	}
+
+	return ValidationEncode{Success: false, Err: "invalid flowchart", Violations: violations}
+}
+
+type VersionEncode struct {
+	Success bool `json:"success"`
+	Version int  `json:"version"`
+}
+
+type ConflictEncode struct {
+	Success bool   `json:"success"`
+	Err     string `json:"error"`
+	Version int    `json:"version"`
+}
+
 type HttpServer struct {
 	App handlers.Application
 }
 
+func formatETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseETag reads the version out of an If-Match header. A wildcard matches any
+// version of a flowchart that exists.
+func parseETag(etag string) (int, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+
+	if etag == "*" {
+		return domain.AnyVersion, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(etag, `"`))
+
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid If-Match header %q", etag)
+	}
+
+	return version, nil
+}
+
+// encodeCommandError answers a failed command with the status matching its error.
+func encodeCommandError(c *fiber.Ctx, err error) error {
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		return c.Status(http.StatusUnprocessableEntity).JSON(encodeValidationError(validationErr))
+	}
+
+	var conflictErr *domain.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		c.Set(fiber.HeaderETag, formatETag(conflictErr.Current))
+		return c.Status(http.StatusConflict).JSON(ConflictEncode{Success: false, Err: err.Error(), Version: conflictErr.Current})
+	}
+
+	if errors.Is(err, domain.ErrVersionNotFound) || errors.Is(err, domain.ErrFlowChartNotFound) ||
+		errors.Is(err, domain.ErrNodeNotFound) || errors.Is(err, domain.ErrEdgeNotFound) {
+		return c.Status(http.StatusNotFound).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if errors.Is(err, domain.ErrPreconditionFailed) {
+		return c.Status(http.StatusPreconditionFailed).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if errors.Is(err, command.ErrReservedKey) {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if errors.Is(err, domain.ErrFlowChartExists) || errors.Is(err, domain.ErrNodeExists) || errors.Is(err, domain.ErrEdgeExists) {
+		return c.Status(http.StatusConflict).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	return c.Status(http.StatusUnprocessableEntity).JSON(Encode{Success: false, Err: err.Error()})
+}
+
 func (h *HttpServer) EditFlowChartUnstructuredData(c *fiber.Ctx) error {
 	ctx := c.Context()
 
@@ -26,8 +127,39 @@ func (h *HttpServer) EditFlowChartUnstructuredData(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
 	}
 
+	if err := applyIfMatch(c, &flowChartDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
 	if err := h.App.Commands.EditFlowChart.Handler(ctx, flowChartDto); err != nil {
-		return c.Status(http.StatusUnprocessableEntity).JSON(Encode{Success: false, Err: err.Error()})
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(Encode{Success: true, Err: ""})
+}
+
+// ImportFlowChart saves a flowchart written in another tool's format, sent as
+// the request body, under the key given in the query or found in the body. An
+// existing flowchart is only replaced when If-Match names the version it is at.
+func (h *HttpServer) ImportFlowChart(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Query("key")
+
+	change, err := changeFromRequest(c)
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	err = h.App.Commands.ImportFlowChart.Handler(ctx, key, c.Query("title"), c.Query("format"), c.Body(), change)
+
+	var syntaxErr *importer.SyntaxError
+	if errors.Is(err, importer.ErrUnknownFormat) || errors.Is(err, command.ErrMissingKey) || errors.As(err, &syntaxErr) {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err != nil {
+		return encodeCommandError(c, err)
 	}
 
 	return c.Status(http.StatusOK).JSON(Encode{Success: true, Err: ""})
@@ -39,9 +171,432 @@ func (h *HttpServer) GetFlowChartUnstructuredData(c *fiber.Ctx) error {
 
 	flowChart, err := h.App.Queries.GetFlowChart.Handler(ctx, key)
 
+	if errors.Is(err, domain.ErrFlowChartNotFound) {
+		return c.Status(http.StatusNotFound).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	c.Set(fiber.HeaderETag, formatETag(flowChart.Version))
+	return c.Status(http.StatusOK).JSON(flowChart)
+}
+
+func (h *HttpServer) ExportFlowChart(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	options := exporter.Options{
+		DotOptions: exporter.DotOptions{IgnorePositions: c.QueryBool("ignore_positions")},
+	}
+
+	document, err := h.App.Queries.ExportFlowChart.Handler(ctx, key, c.Query("format"), options)
+
+	if errors.Is(err, domain.ErrFlowChartNotFound) {
+		return c.Status(http.StatusNotFound).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, document.ContentType)
+	return c.Status(http.StatusOK).Send(document.Body)
+}
+
+func (h *HttpServer) RenderFlowChartSvg(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	document, err := h.App.Queries.ExportFlowChart.Handler(ctx, key, exporter.SvgFormat, exporter.Options{})
+
+	// Flowcharts saved before .svg keys were rejected are still found under
+	// their whole key by the route that follows.
+	if errors.Is(err, domain.ErrFlowChartNotFound) {
+		return c.Next()
+	}
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, document.ContentType)
+	return c.Status(http.StatusOK).Send(document.Body)
+}
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+func (h *HttpServer) ListFlowCharts(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	limit := c.QueryInt("limit", defaultPageLimit)
+	offset := c.QueryInt("offset", 0)
+
+	if limit < 1 || limit > maxPageLimit || offset < 0 {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: fmt.Sprintf("limit must be between 1 and %d and offset not negative", maxPageLimit)})
+	}
+
+	page, err := h.App.Queries.ListFlowCharts.Handler(ctx, limit, offset)
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	return c.Status(http.StatusOK).JSON(page)
+}
+
+func (h *HttpServer) FlowChartExists(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	exists, err := h.App.Queries.FlowChartExists.Handler(ctx, key)
+
+	if err != nil {
+		return c.SendStatus(http.StatusInternalServerError)
+	}
+
+	if !exists {
+		return c.SendStatus(http.StatusNotFound)
+	}
+
+	return c.SendStatus(http.StatusOK)
+}
+
+// applyIfMatch takes the expected version from the If-Match header, which wins
+// over the version sent in the body.
+func applyIfMatch(c *fiber.Ctx, version **int) error {
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+
+	if ifMatch == "" {
+		return nil
+	}
+
+	expected, err := parseETag(ifMatch)
+
+	if err != nil {
+		return err
+	}
+
+	*version = &expected
+	return nil
+}
+
+func (h *HttpServer) ReplaceFlowChartUnstructuredData(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	flowChartDto := &transport.FlowChartDto[transport.UnstructuredDataDto]{}
+
+	if err := c.BodyParser(flowChartDto); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := applyIfMatch(c, &flowChartDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	created, err := h.App.Commands.ReplaceFlowChart.Handler(ctx, key, flowChartDto)
+
+	if errors.Is(err, command.ErrKeyMismatch) {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	if created {
+		return c.Status(http.StatusCreated).JSON(Encode{Success: true, Err: ""})
+	}
+
+	return c.Status(http.StatusOK).JSON(Encode{Success: true, Err: ""})
+}
+
+func (h *HttpServer) PatchFlowChartUnstructuredData(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	switch mediaType(c.Get(fiber.HeaderContentType)) {
+	case mimeJsonPatch:
+		return h.jsonPatchFlowChart(c, command.JsonPatch)
+	case mimeMergePatch:
+		return h.jsonPatchFlowChart(c, command.MergePatch)
+	}
+
+	patchDto := &transport.FlowChartPatchDto[transport.UnstructuredDataDto]{}
+
+	if err := c.BodyParser(patchDto); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := applyIfMatch(c, &patchDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := h.App.Commands.PatchFlowChart.Handler(ctx, key, patchDto); err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(Encode{Success: true, Err: ""})
+}
+
+const (
+	mimeJsonPatch  = "application/json-patch+json"
+	mimeMergePatch = "application/merge-patch+json"
+)
+
+func mediaType(contentType string) string {
+	mime, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mime))
+}
+
+func (h *HttpServer) jsonPatchFlowChart(c *fiber.Ctx, format command.PatchFormat) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	change, err := changeFromRequest(c)
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	err = h.App.Commands.JsonPatch.Handler(ctx, key, c.Body(), format, change)
+
+	if errors.Is(err, command.ErrInvalidPatch) || errors.Is(err, command.ErrKeyMismatch) {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(Encode{Success: true, Err: ""})
+}
+
+func (h *HttpServer) DeleteFlowChart(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	if err := h.App.Commands.DeleteFlowChart.Handler(ctx, key); err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// changeFromRequest reads the change metadata of requests without a body.
+func changeFromRequest(c *fiber.Ctx) (transport.ChangeDto, error) {
+	change := transport.ChangeDto{
+		Author:  c.Query("author"),
+		Message: c.Query("message"),
+	}
+
+	err := applyIfMatch(c, &change.Version)
+	return change, err
+}
+
+// nodeResponse is the node sent back once it was created or changed.
+func nodeResponse(node *domain.Node[domain.UnstructuredDataDomain]) *transport.NodeDto[transport.UnstructuredDataDto] {
+	return transport.NodeFromDomain(node, func(data domain.UnstructuredDataDomain) transport.UnstructuredDataDto {
+		return data
+	})
+}
+
+func (h *HttpServer) CreateNodeUnstructuredData(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+	id := c.Params("id")
+
+	nodeDto := &transport.NodeCreateDto[transport.UnstructuredDataDto]{}
+
+	if err := c.BodyParser(nodeDto); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := applyIfMatch(c, &nodeDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	node, err := h.App.Commands.EditNode.Create(ctx, key, id, nodeDto)
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusCreated).JSON(nodeResponse(node))
+}
+
+func (h *HttpServer) UpdateNodeUnstructuredData(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+	id := c.Params("id")
+
+	nodeDto := &transport.NodePatchDto[transport.UnstructuredDataDto]{}
+
+	if err := c.BodyParser(nodeDto); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := applyIfMatch(c, &nodeDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	node, err := h.App.Commands.EditNode.Update(ctx, key, id, nodeDto)
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(nodeResponse(node))
+}
+
+func (h *HttpServer) DeleteNode(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+	id := c.Params("id")
+
+	change, err := changeFromRequest(c)
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := h.App.Commands.EditNode.Delete(ctx, key, id, c.QueryBool("subtree"), change); err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *HttpServer) CreateEdge(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+	id := c.Params("id")
+
+	edgeDto := &transport.EdgeCreateDto{}
+
+	if err := c.BodyParser(edgeDto); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := applyIfMatch(c, &edgeDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	edge, err := h.App.Commands.EditEdge.Create(ctx, key, id, edgeDto)
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusCreated).JSON(transport.EdgeFromDomain(edge))
+}
+
+func (h *HttpServer) UpdateEdge(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+	id := c.Params("id")
+
+	edgeDto := &transport.EdgePatchDto{}
+
+	if err := c.BodyParser(edgeDto); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := applyIfMatch(c, &edgeDto.Version); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	edge, err := h.App.Commands.EditEdge.Update(ctx, key, id, edgeDto)
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(transport.EdgeFromDomain(edge))
+}
+
+func (h *HttpServer) DeleteEdge(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+	id := c.Params("id")
+
+	change, err := changeFromRequest(c)
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	if err := h.App.Commands.EditEdge.Delete(ctx, key, id, change); err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *HttpServer) ListFlowChartVersions(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	versions, err := h.App.Queries.ListFlowChartVersions.Handler(ctx, key)
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
+	return c.Status(http.StatusOK).JSON(versions)
+}
+
+func (h *HttpServer) GetFlowChartVersion(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	version, err := strconv.Atoi(c.Params("version"))
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: "version must be a number"})
+	}
+
+	flowChart, err := h.App.Queries.GetFlowChartVersion.Handler(ctx, key, version)
+
+	if errors.Is(err, domain.ErrVersionNotFound) {
+		return c.Status(http.StatusNotFound).JSON(Encode{Success: false, Err: err.Error()})
+	}
+
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
 	}
 
 	return c.Status(http.StatusOK).JSON(flowChart)
 }
+
+func (h *HttpServer) RestoreFlowChartVersion(c *fiber.Ctx) error {
+	ctx := c.Context()
+	key := c.Params("key")
+
+	version, err := strconv.Atoi(c.Params("version"))
+
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: "version must be a number"})
+	}
+
+	restoreDto := &transport.RestoreVersionDto{}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(restoreDto); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(Encode{Success: false, Err: err.Error()})
+		}
+	}
+
+	head, err := h.App.Commands.RestoreFlowChart.Handler(ctx, key, version, restoreDto)
+
+	if err != nil {
+		return encodeCommandError(c, err)
+	}
+
+	return c.Status(http.StatusOK).JSON(VersionEncode{Success: true, Version: head})
+}
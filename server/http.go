@@ -19,8 +19,25 @@ func RunHttpServer(addr string, application handlers.Application) {
 	httpServer := ports.HttpServer{App: application}
 
 	apiV1 := app.Group("api/v1")
+	apiV1.Get("/flowcharts", httpServer.ListFlowCharts)
 	apiV1.Post("/flowchart", httpServer.EditFlowChartUnstructuredData)
+	apiV1.Post("/flowchart/import", httpServer.ImportFlowChart)
+	apiV1.Head("/flowchart/:key", httpServer.FlowChartExists)
+	apiV1.Get("/flowchart/:key.svg", httpServer.RenderFlowChartSvg)
 	apiV1.Get("/flowchart/:key", httpServer.GetFlowChartUnstructuredData)
+	apiV1.Put("/flowchart/:key", httpServer.ReplaceFlowChartUnstructuredData)
+	apiV1.Patch("/flowchart/:key", httpServer.PatchFlowChartUnstructuredData)
+	apiV1.Delete("/flowchart/:key", httpServer.DeleteFlowChart)
+	apiV1.Get("/flowchart/:key/export", httpServer.ExportFlowChart)
+	apiV1.Post("/flowchart/:key/nodes/:id", httpServer.CreateNodeUnstructuredData)
+	apiV1.Patch("/flowchart/:key/nodes/:id", httpServer.UpdateNodeUnstructuredData)
+	apiV1.Delete("/flowchart/:key/nodes/:id", httpServer.DeleteNode)
+	apiV1.Post("/flowchart/:key/edges/:id", httpServer.CreateEdge)
+	apiV1.Patch("/flowchart/:key/edges/:id", httpServer.UpdateEdge)
+	apiV1.Delete("/flowchart/:key/edges/:id", httpServer.DeleteEdge)
+	apiV1.Get("/flowchart/:key/versions", httpServer.ListFlowChartVersions)
+	apiV1.Get("/flowchart/:key/versions/:version", httpServer.GetFlowChartVersion)
+	apiV1.Post("/flowchart/:key/versions/:version/restore", httpServer.RestoreFlowChartVersion)
 
 	logrus.Info("Starting HTTP server")
 	app.Listen(addr)
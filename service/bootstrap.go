@@ -5,25 +5,93 @@ import (
 	"flowChart/handlers"
 	"flowChart/handlers/command"
 	"flowChart/handlers/queries"
+	"flowChart/settings"
+	"log"
 )
 
+const (
+	PostgresStorage = "postgres"
+	SqliteStorage   = "sqlite"
+	MemoryStorage   = "memory"
+)
+
+// Bootstrap builds the application on the storage named by the STORAGE
+// environment variable, Postgres when it is not set.
 func Bootstrap() handlers.Application {
-	config := &DatabaseConfig{}
-	config.Parse()
-	newPsqlClient := NewPostgresDb(config)
+	switch storage := settings.GETENVDEFAULT("STORAGE", PostgresStorage); storage {
+	case PostgresStorage:
+		config := &DatabaseConfig{}
+		config.Parse()
+		newPsqlClient := NewPostgresDb(config)
+
+		return NewApplication(
+			adapters.NewWriteFlowChartUnstructuredDataAgg(newPsqlClient),
+			adapters.NewReadFlowChartUnstructuredDataAgg(newPsqlClient),
+		)
+	case SqliteStorage:
+		config := &SqliteConfig{}
+		config.Parse()
+		newSqliteClient := NewSqliteDb(config)
+
+		return NewApplication(
+			adapters.NewSqliteWriteFlowChartUnstructuredDataAgg(newSqliteClient),
+			adapters.NewSqliteReadFlowChartUnstructuredDataAgg(newSqliteClient),
+		)
+	case MemoryStorage:
+		return NewMemoryApplication()
+	default:
+		log.Fatalf("unknown STORAGE %q, expected %q, %q or %q", storage, PostgresStorage, SqliteStorage, MemoryStorage)
+		return handlers.Application{}
+	}
+}
 
-	writeFlowChartUnstructuredDataAgr := adapters.NewWriteFlowChartUnstructuredDataAgg(newPsqlClient)
-	readFlowChartUnstructuredDataAgr := adapters.NewReadFlowChartUnstructuredDataAgg(newPsqlClient)
+// NewMemoryApplication builds the application on a fresh in-memory store, so
+// it runs without a database.
+func NewMemoryApplication() handlers.Application {
+	store := adapters.NewMemoryFlowChartStore()
+
+	return NewApplication(
+		adapters.NewMemoryWriteFlowChartUnstructuredDataAgg(store),
+		adapters.NewMemoryReadFlowChartUnstructuredDataAgg(store),
+	)
+}
 
+func NewApplication(writeFlowChartUnstructuredDataAgr *adapters.WriteFlowChartUnstructuredDataAgg, readFlowChartUnstructuredDataAgr *adapters.ReadFlowChartUnstructuredDataAgg) handlers.Application {
 	editFlowChart := command.NewHandlerFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	replaceFlowChart := command.NewHandlerReplaceFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	patchFlowChart := command.NewHandlerPatchFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	jsonPatch := command.NewHandlerJsonPatchFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	deleteFlowChart := command.NewHandlerDeleteFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	editNode := command.NewHandlerNodeFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	editEdge := command.NewHandlerEdgeFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	restoreFlowChart := command.NewHandlerRestoreFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
+	importFlowChart := command.NewHandlerImportFlowChartUnstructuredData(writeFlowChartUnstructuredDataAgr)
 	getFlowChart := queries.NewHandlerGetFlowChartUnstructuredData(readFlowChartUnstructuredDataAgr)
+	listFlowCharts := queries.NewHandlerListFlowChartsUnstructuredData(readFlowChartUnstructuredDataAgr)
+	flowChartExists := queries.NewHandlerFlowChartExistsUnstructuredData(readFlowChartUnstructuredDataAgr)
+	listFlowChartVersions := queries.NewHandlerListFlowChartVersionsUnstructuredData(readFlowChartUnstructuredDataAgr)
+	getFlowChartVersion := queries.NewHandlerGetFlowChartVersionUnstructuredData(readFlowChartUnstructuredDataAgr)
+	exportFlowChart := queries.NewHandlerExportFlowChartUnstructuredData(readFlowChartUnstructuredDataAgr)
 
 	return handlers.Application{
 		Commands: handlers.Commands{
-			EditFlowChart: editFlowChart,
+			EditFlowChart:    editFlowChart,
+			ReplaceFlowChart: replaceFlowChart,
+			PatchFlowChart:   patchFlowChart,
+			JsonPatch:        jsonPatch,
+			DeleteFlowChart:  deleteFlowChart,
+			EditNode:         editNode,
+			EditEdge:         editEdge,
+			RestoreFlowChart: restoreFlowChart,
+			ImportFlowChart:  importFlowChart,
 		},
 		Queries: handlers.Queries{
-			GetFlowChart: getFlowChart,
+			GetFlowChart:          getFlowChart,
+			ListFlowCharts:        listFlowCharts,
+			FlowChartExists:       flowChartExists,
+			ListFlowChartVersions: listFlowChartVersions,
+			GetFlowChartVersion:   getFlowChartVersion,
+			ExportFlowChart:       exportFlowChart,
 		},
 	}
 }
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
+	"flowChart/migrations"
 	"flowChart/settings"
 
 	"github.com/jmoiron/sqlx"
@@ -25,6 +27,7 @@ type DatabaseConfig struct {
 	ReadTimeout        int
 	WriteTimeout       int
 	Timeout            int
+	AutoMigrate        bool
 }
 
 func (conf *DatabaseConfig) Parse() {
@@ -33,6 +36,7 @@ func (conf *DatabaseConfig) Parse() {
 	conf.Host = settings.GETENV("POSTGRES_HOST")
 	conf.Port = settings.GETENV("POSTGRES_PORT")
 	conf.Database = settings.GETENV("POSTGRES_DB_NAME")
+	conf.AutoMigrate = settings.GETENVDEFAULT("AUTO_MIGRATE", "true") == "true"
 }
 
 func NewPostgresDb(conf *DatabaseConfig) *sqlx.DB {
@@ -64,5 +68,64 @@ func NewPostgresDb(conf *DatabaseConfig) *sqlx.DB {
 	mydb.SetConnMaxLifetime(time.Duration(30) * time.Millisecond)
 	mydb.SetConnMaxIdleTime(time.Duration(30) * time.Millisecond)
 
+	if conf.AutoMigrate {
+		migrator, err := migrations.NewPostgresMigrator(mydb)
+		if err != nil {
+			log.Fatal(err)
+		}
+		migrate(migrator)
+	}
+
 	return mydb
 }
+
+type SqliteConfig struct {
+	Path string
+}
+
+func (conf *SqliteConfig) Parse() {
+	conf.Path = settings.GETENVDEFAULT("SQLITE_PATH", "nodeflow.db")
+}
+
+// NewSqliteDb opens the SQLite database at the configured path and brings its
+// schema up to date.
+func NewSqliteDb(conf *SqliteConfig) *sqlx.DB {
+	mydb := openSqliteDb(conf)
+
+	migrator, err := migrations.NewSqliteMigrator(mydb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrate(migrator)
+
+	return mydb
+}
+
+func openSqliteDb(conf *SqliteConfig) *sqlx.DB {
+	// immediate transactions take the write lock up front, so two saves cannot
+	// both pass the version check.
+	dns := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", conf.Path)
+
+	mydb, err := sqlx.Open("sqlite3", dns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = mydb.Ping(); err != nil {
+		panic(err)
+	}
+
+	return mydb
+}
+
+func migrate(migrator *migrations.Migrator) {
+	versions, err := migrator.Up(context.Background())
+
+	for _, version := range versions {
+		log.Printf("applied migration %d", version)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
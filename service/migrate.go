@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"flowChart/migrations"
+	"flowChart/settings"
+)
+
+// Migrate runs the migrate command line: "up", "down [steps]" or "status",
+// against the database selected by STORAGE.
+func Migrate(args []string) error {
+	migrator, err := newMigrator()
+
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		versions, err := migrator.Up(ctx)
+		for _, version := range versions {
+			fmt.Printf("applied migration %d\n", version)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("down expects a positive number of steps, got %q", args[1])
+			}
+		}
+
+		versions, err := migrator.Down(ctx, steps)
+		for _, version := range versions {
+			fmt.Printf("reverted migration %d\n", version)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+}
+
+func newMigrator() (*migrations.Migrator, error) {
+	switch storage := settings.GETENVDEFAULT("STORAGE", PostgresStorage); storage {
+	case PostgresStorage:
+		config := &DatabaseConfig{}
+		config.Parse()
+		config.AutoMigrate = false
+
+		return migrations.NewPostgresMigrator(NewPostgresDb(config))
+	case SqliteStorage:
+		config := &SqliteConfig{}
+		config.Parse()
+
+		return migrations.NewSqliteMigrator(openSqliteDb(config))
+	default:
+		return nil, fmt.Errorf("STORAGE %q has no migrations", storage)
+	}
+}
@@ -31,3 +31,12 @@ func GETENV(key string) string {
 	}
 	return env
 }
+
+func GETENVDEFAULT(key string, fallback string) string {
+	env, isPresent := os.LookupEnv(key)
+
+	if !isPresent {
+		return fallback
+	}
+	return env
+}
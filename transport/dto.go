@@ -1,5 +1,10 @@
 package transport
 
+import (
+	"encoding/json"
+	"flowChart/domain"
+)
+
 type DataDto struct {
 	Label string `json:"label"`
 }
@@ -7,10 +12,150 @@ type DataDto struct {
 type UnstructuredDataDto interface{}
 
 type FlowChartDto[T comparable] struct {
-	Title string        `json:"title"`
-	Key   string        `json:"key"`
-	Nodes []*NodeDto[T] `json:"nodes"`
-	Edges []*EdgeDto    `json:"Edges"`
+	Title    string        `json:"title"`
+	Key      string        `json:"key"`
+	Version  *int          `json:"version,omitempty"`
+	Author   string        `json:"author,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Nodes    []*NodeDto[T] `json:"nodes"`
+	Edges    []*EdgeDto    `json:"edges"`
+	Viewport *ViewportDto  `json:"viewport,omitempty"`
+}
+
+// FlowChartPatchDto carries a partial change to a flowchart: title and viewport
+// are changed only when present, and nodes and edges replace the stored ones
+// together.
+type FlowChartPatchDto[T comparable] struct {
+	Title    *string       `json:"title"`
+	Version  *int          `json:"version,omitempty"`
+	Author   string        `json:"author,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Nodes    []*NodeDto[T] `json:"nodes"`
+	Edges    []*EdgeDto    `json:"edges"`
+	Viewport *ViewportDto  `json:"viewport"`
+}
+
+// ChangeDto describes a change saved through the node and edge endpoints.
+type ChangeDto struct {
+	Version *int   `json:"version,omitempty"`
+	Author  string `json:"author,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type NodeCreateDto[T comparable] struct {
+	NodeDto[T]
+	ChangeDto
+	Parent string `json:"parent"`
+}
+
+func (d *NodeCreateDto[T]) UnmarshalJSON(data []byte) error {
+	parent := struct {
+		Parent string `json:"parent"`
+	}{}
+
+	extra, err := SplitExtra(data, (*plainNodeDto[T])(&d.NodeDto), &d.ChangeDto, &parent)
+	d.Extra, d.Parent = extra, parent.Parent
+
+	return err
+}
+
+// Nullable tells a field sent as null, which clears it, from a field left out.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	n.Value = nil
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	n.Value = new(T)
+	return json.Unmarshal(data, n.Value)
+}
+
+// NodePatchDto changes only the fields present. Setting parent moves the node,
+// with its subtree, under another node. Optional fields sent as null are
+// cleared, and fields the flowchart does not use are kept in Extra.
+type NodePatchDto[T comparable] struct {
+	ChangeDto
+	Parent           *string         `json:"parent"`
+	Position         *PositionDto    `json:"position"`
+	Data             *T              `json:"data"`
+	Width            *float64        `json:"width"`
+	Height           *float64        `json:"height"`
+	Selected         *bool           `json:"selected"`
+	PositionAbsolute *PositionDto    `json:"positionAbsolute"`
+	Dragging         *bool           `json:"dragging"`
+	Type             *string         `json:"type"`
+	ParentNode       *string         `json:"parentNode"`
+	ParentId         *string         `json:"parentId"`
+	Extent           json.RawMessage `json:"extent"`
+	ZIndex           Nullable[int]   `json:"zIndex"`
+	Hidden           *bool           `json:"hidden"`
+	Style            json.RawMessage `json:"style"`
+	Extra            domain.Extra    `json:"-"`
+}
+
+type plainNodePatchDto[T comparable] NodePatchDto[T]
+
+func (d *NodePatchDto[T]) UnmarshalJSON(data []byte) error {
+	extra, err := SplitExtra(data, (*plainNodePatchDto[T])(d))
+	d.Extra = extra
+	return err
+}
+
+type EdgeCreateDto struct {
+	EdgeDto
+	ChangeDto
+}
+
+func (d *EdgeCreateDto) UnmarshalJSON(data []byte) error {
+	extra, err := SplitExtra(data, (*plainEdgeDto)(&d.EdgeDto), &d.ChangeDto)
+	d.Extra = extra
+	return err
+}
+
+// EdgePatchDto changes only the fields present. Optional fields sent as null
+// are cleared, and fields the flowchart does not use are kept in Extra.
+type EdgePatchDto struct {
+	ChangeDto
+	Source       *string          `json:"source"`
+	Target       *string          `json:"target"`
+	SourceHandle Nullable[string] `json:"sourceHandle"`
+	TargetHandle Nullable[string] `json:"targetHandle"`
+	Label        *string          `json:"label"`
+	Type         *string          `json:"type"`
+	Animated     Nullable[bool]   `json:"animated"`
+	Style        json.RawMessage  `json:"style"`
+	Data         json.RawMessage  `json:"data"`
+	Hidden       *bool            `json:"hidden"`
+	ZIndex       Nullable[int]    `json:"zIndex"`
+	MarkerStart  json.RawMessage  `json:"markerStart"`
+	MarkerEnd    json.RawMessage  `json:"markerEnd"`
+	Extra        domain.Extra     `json:"-"`
+}
+
+type plainEdgePatchDto EdgePatchDto
+
+func (d *EdgePatchDto) UnmarshalJSON(data []byte) error {
+	extra, err := SplitExtra(data, (*plainEdgePatchDto)(d))
+	d.Extra = extra
+	return err
+}
+
+type RestoreVersionDto struct {
+	Author  string `json:"author"`
+	Message string `json:"message"`
+}
+
+type ViewportDto struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Zoom float64 `json:"zoom"`
 }
 
 type PositionDto struct {
@@ -22,18 +167,66 @@ type NodeDto[T comparable] struct {
 	Id               string      `json:"id"`
 	Position         PositionDto `json:"position"`
 	Data             T           `json:"data"`
-	Width            int16       `json:"width"`
-	Height           int16       `json:"height"`
+	Width            float64     `json:"width"`
+	Height           float64     `json:"height"`
 	Selected         bool        `json:"selected"`
 	PositionAbsolute PositionDto `json:"positionAbsolute"`
 	Dragging         bool        `json:"dragging"`
 	Type             string      `json:"type"`
+
+	// React Flow 11 calls the group parent parentNode, React Flow 12 parentId.
+	ParentNode string          `json:"parentNode,omitempty"`
+	ParentId   string          `json:"parentId,omitempty"`
+	Extent     json.RawMessage `json:"extent,omitempty"`
+	ZIndex     *int            `json:"zIndex,omitempty"`
+	Hidden     bool            `json:"hidden,omitempty"`
+	Style      json.RawMessage `json:"style,omitempty"`
+
+	// Extra keeps the fields the flowchart does not use, such as className or
+	// measured, to send them back with the node.
+	Extra domain.Extra `json:"-"`
+}
+
+type plainNodeDto[T comparable] NodeDto[T]
+
+func (n NodeDto[T]) MarshalJSON() ([]byte, error) {
+	return JoinExtra(plainNodeDto[T](n), n.Extra)
+}
+
+func (n *NodeDto[T]) UnmarshalJSON(data []byte) error {
+	extra, err := SplitExtra(data, (*plainNodeDto[T])(n))
+	n.Extra = extra
+	return err
 }
 
 type EdgeDto struct {
-	Id     string `json:"id"`
-	Source string `json:"source"`
-	Target string `json:"target"`
+	Id           string          `json:"id"`
+	Source       string          `json:"source"`
+	Target       string          `json:"target"`
+	SourceHandle *string         `json:"sourceHandle"`
+	TargetHandle *string         `json:"targetHandle"`
+	Label        string          `json:"label,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	Animated     *bool           `json:"animated,omitempty"`
+	Style        json.RawMessage `json:"style,omitempty"`
+	Data         json.RawMessage `json:"data,omitempty"`
+	Hidden       bool            `json:"hidden,omitempty"`
+	ZIndex       *int            `json:"zIndex,omitempty"`
+	MarkerStart  json.RawMessage `json:"markerStart,omitempty"`
+	MarkerEnd    json.RawMessage `json:"markerEnd,omitempty"`
+	Extra        domain.Extra    `json:"-"`
+}
+
+type plainEdgeDto EdgeDto
+
+func (e EdgeDto) MarshalJSON() ([]byte, error) {
+	return JoinExtra(plainEdgeDto(e), e.Extra)
+}
+
+func (e *EdgeDto) UnmarshalJSON(data []byte) error {
+	extra, err := SplitExtra(data, (*plainEdgeDto)(e))
+	e.Extra = extra
+	return err
 }
 
 var FlowChartJson string = `{
@@ -193,5 +386,10 @@ var FlowChartJson string = `{
 		"source": "2",
 		"target": "6"
 	}
-]
+],
+"viewport": {
+	"x": 0,
+	"y": 0,
+	"zoom": 1
+}
 }`
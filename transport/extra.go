@@ -0,0 +1,121 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"flowChart/domain"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var knownKeys sync.Map
+
+// jsonKeys lists the JSON names of the fields of a struct type, in lower case
+// since encoding/json matches keys to fields regardless of case.
+func jsonKeys(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if keys, ok := knownKeys.Load(t); ok {
+		return keys.(map[string]bool)
+	}
+
+	keys := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		switch {
+		case name == "-":
+		case field.Anonymous && name == "":
+			for key := range jsonKeys(field.Type) {
+				keys[key] = true
+			}
+		case name == "":
+			keys[strings.ToLower(field.Name)] = true
+		default:
+			keys[strings.ToLower(name)] = true
+		}
+	}
+
+	knownKeys.Store(t, keys)
+
+	return keys
+}
+
+// SplitExtra decodes a JSON object into each of values and returns the fields
+// none of them has a place for. A key differing from a field name only in case
+// goes to the field, as encoding/json decodes it there.
+func SplitExtra(data []byte, values ...any) (domain.Extra, error) {
+	for _, value := range values {
+		if err := json.Unmarshal(data, value); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		known := jsonKeys(reflect.TypeOf(value))
+
+		for key := range fields {
+			if known[strings.ToLower(key)] {
+				delete(fields, key)
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return fields, nil
+}
+
+// JoinExtra encodes value as a JSON object and adds the extra fields after its
+// own, sorted by name. Fields value already has are not added again.
+func JoinExtra(value any, extra domain.Extra) ([]byte, error) {
+	data, err := json.Marshal(value)
+
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	known := jsonKeys(reflect.TypeOf(value))
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !known[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	buffer := bytes.NewBuffer(bytes.TrimSuffix(data, []byte("}")))
+	empty := buffer.Len() == 1
+
+	for _, name := range names {
+		if !empty {
+			buffer.WriteByte(',')
+		}
+		empty = false
+
+		key, _ := json.Marshal(name)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+
+		if err := json.Compact(buffer, extra[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNodeExtraIgnoresKeyCase(t *testing.T) {
+	var node NodeDto[UnstructuredDataDto]
+
+	if err := json.Unmarshal([]byte(`{"id":"a","Width":150,"className":"wide"}`), &node); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if node.Width != 150 || len(node.Extra) != 1 || string(node.Extra["className"]) != `"wide"` {
+		t.Errorf("width %g with extra %v, want Width read as width and only className kept", node.Width, node.Extra)
+	}
+
+	node.Extra["ID"] = json.RawMessage(`"b"`)
+
+	data, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if _, ok := fields["ID"]; ok || string(fields["id"]) != `"a"` {
+		t.Errorf("node written as %s, want an extra field named like id left out", data)
+	}
+}
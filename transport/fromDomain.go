@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"flowChart/domain"
+)
+
+// FromDomain turns a flowchart back into the dto it is sent as, listing the
+// nodes in the order React Flow draws them.
+func FromDomain[D any, R comparable](flowChart *domain.FlowChart[D], dataParse func(data D) R) *FlowChartDto[R] {
+	nodes := flowChart.DrawOrder()
+	version := flowChart.Version
+
+	dto := &FlowChartDto[R]{
+		Title:    flowChart.Title,
+		Key:      flowChart.Key,
+		Version:  &version,
+		Nodes:    make([]*NodeDto[R], 0, len(nodes)),
+		Edges:    make([]*EdgeDto, 0, len(flowChart.Edges)),
+		Viewport: &ViewportDto{X: flowChart.Viewport.X, Y: flowChart.Viewport.Y, Zoom: flowChart.Viewport.Zoom},
+	}
+
+	for _, node := range nodes {
+		dto.Nodes = append(dto.Nodes, NodeFromDomain(node, dataParse))
+	}
+
+	for _, edge := range flowChart.Edges {
+		dto.Edges = append(dto.Edges, EdgeFromDomain(edge))
+	}
+
+	return dto
+}
+
+func NodeFromDomain[D any, R comparable](node *domain.Node[D], dataParse func(data D) R) *NodeDto[R] {
+	return &NodeDto[R]{
+		Id:               node.NodeID,
+		Position:         PositionDto{X: node.Position.X, Y: node.Position.Y},
+		Data:             dataParse(node.Data),
+		Width:            node.Width,
+		Height:           node.Height,
+		Selected:         node.Selected,
+		PositionAbsolute: PositionDto{X: node.PositionAbsolute.X, Y: node.PositionAbsolute.Y},
+		Dragging:         node.Dragging,
+		Type:             node.Type,
+		ParentNode:       node.ParentNode,
+		ParentId:         node.ParentNode,
+		Extent:           node.Extent,
+		ZIndex:           node.ZIndex,
+		Hidden:           node.Hidden,
+		Style:            node.Style,
+		Extra:            node.Extra,
+	}
+}
+
+func EdgeFromDomain(edge *domain.Edge) *EdgeDto {
+	return &EdgeDto{
+		Id:           edge.ID,
+		Source:       edge.Source,
+		Target:       edge.Target,
+		SourceHandle: edge.SourceHandle,
+		TargetHandle: edge.TargetHandle,
+		Label:        edge.Label,
+		Type:         edge.Type,
+		Animated:     edge.Animated,
+		Style:        edge.Style,
+		Data:         edge.Data,
+		Hidden:       edge.Hidden,
+		ZIndex:       edge.ZIndex,
+		MarkerStart:  edge.MarkerStart,
+		MarkerEnd:    edge.MarkerEnd,
+		Extra:        edge.Extra,
+	}
+}
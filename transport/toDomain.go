@@ -1,56 +1,90 @@
 package transport
 
 import (
-	"errors"
 	"flowChart/domain"
-	"sync"
 )
 
 func ToDomain[R comparable, D comparable](flowChart *FlowChartDto[R], dataParse func(request R) D) (*domain.FlowChart[D], error) {
-	m := sync.RWMutex{}
-
-	nodeMap := func() map[string]*domain.Node[D] {
-		nodeM := make(map[string]*domain.Node[D], len(flowChart.Nodes))
-		for _, n := range flowChart.Nodes {
-			m.Lock()
-			data := dataParse(n.Data)
-			nodeM[n.Id] = domain.NewNode(n.Id, data, domain.Position{X: n.Position.X, Y: n.Position.Y},
-				n.Width, n.Height, n.Selected, domain.Position{X: n.PositionAbsolute.X, Y: n.PositionAbsolute.Y}, n.Dragging, n.Type)
-			m.Unlock()
-		}
-		return nodeM
-	}()
+	if err := domain.ValidateEntries(flowChart.Nodes, flowChart.Edges); err != nil {
+		return nil, err
+	}
 
-	var flow *domain.FlowChart[D]
-	var root *domain.Node[D]
+	nodes := make([]*domain.Node[D], 0, len(flowChart.Nodes))
+	for _, n := range flowChart.Nodes {
+		nodes = append(nodes, NodeToDomain(n, dataParse))
+	}
 
+	edges := make([]*domain.Edge, 0, len(flowChart.Edges))
 	for _, edge := range flowChart.Edges {
+		edges = append(edges, EdgeToDomain(edge))
+	}
+
+	var flow *domain.FlowChart[D]
+
+	if err := domain.Validate(nodes, edges); err != nil {
+		return flow, err
+	}
 
-		if err := func() error {
-			m.RLock()
-			defer m.RUnlock()
-			parent, ok := nodeMap[edge.Source]
+	nodeMap := make(map[string]*domain.Node[D], len(nodes))
+	for _, node := range nodes {
+		nodeMap[node.NodeID] = node
+	}
 
-			if !ok {
-				return errors.New("Parent Not Found")
-			}
+	root := domain.FindRoot(nodes, edges)
 
-			if edge.Source == "0" {
-				root = parent
-			}
+	domain.BuildTree(root, nodeMap, edges)
 
-			if child, ok := nodeMap[edge.Id]; ok {
-				parent.AddChild(child)
-				return nil
-			}
+	return &domain.FlowChart[D]{
+		Title:    flowChart.Title,
+		Node:     root,
+		Key:      flowChart.Key,
+		Edges:    edges,
+		Viewport: ViewportToDomain(flowChart.Viewport),
+		Author:   flowChart.Author,
+		Message:  flowChart.Message,
 
-			return errors.New("Child Not Found")
+		ExpectedVersion: flowChart.Version,
+	}, nil
+}
 
-		}(); err != nil {
-			return flow, err
-		}
+func NodeToDomain[R comparable, D comparable](n *NodeDto[R], dataParse func(request R) D) *domain.Node[D] {
+	node := domain.NewNode(n.Id, dataParse(n.Data), domain.Position{X: n.Position.X, Y: n.Position.Y},
+		n.Width, n.Height, n.Selected, domain.Position{X: n.PositionAbsolute.X, Y: n.PositionAbsolute.Y}, n.Dragging, n.Type)
+	node.ParentNode = n.ParentId
+	if node.ParentNode == "" {
+		node.ParentNode = n.ParentNode
+	}
+	node.Extent = n.Extent
+	node.ZIndex = n.ZIndex
+	node.Hidden = n.Hidden
+	node.Style = n.Style
+	node.Extra = n.Extra
+	return node
+}
 
+// ViewportToDomain falls back to the viewport React Flow starts with when the
+// flowchart was sent without one.
+func ViewportToDomain(viewport *ViewportDto) domain.Viewport {
+	if viewport == nil {
+		return domain.DefaultViewport
 	}
 
-	return &domain.FlowChart[D]{Title: flowChart.Title, Node: root, Key: flowChart.Key}, nil
+	return domain.Viewport{X: viewport.X, Y: viewport.Y, Zoom: viewport.Zoom}
+}
+
+func EdgeToDomain(edge *EdgeDto) *domain.Edge {
+	e := domain.NewEdge(edge.Id, edge.Source, edge.Target)
+	e.SourceHandle = edge.SourceHandle
+	e.TargetHandle = edge.TargetHandle
+	e.Label = edge.Label
+	e.Type = edge.Type
+	e.Animated = edge.Animated
+	e.Style = edge.Style
+	e.Data = edge.Data
+	e.Hidden = edge.Hidden
+	e.ZIndex = edge.ZIndex
+	e.MarkerStart = edge.MarkerStart
+	e.MarkerEnd = edge.MarkerEnd
+	e.Extra = edge.Extra
+	return e
 }
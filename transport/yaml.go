@@ -0,0 +1,358 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"flowChart/domain"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFlowChart is the YAML document a flowchart is kept in. It leaves out the
+// state that only matters while the flowchart is open in the editor, such as
+// selection, and every field left at its default, so the file stays short.
+type yamlFlowChart[T comparable] struct {
+	Title    string        `yaml:"title,omitempty"`
+	Key      string        `yaml:"key,omitempty"`
+	Viewport *yamlViewport `yaml:"viewport,omitempty,flow"`
+	Nodes    []yamlNode[T] `yaml:"nodes"`
+	Edges    []yamlEdge    `yaml:"edges"`
+}
+
+type yamlViewport struct {
+	X    float64 `yaml:"x"`
+	Y    float64 `yaml:"y"`
+	Zoom float64 `yaml:"zoom"`
+}
+
+type yamlPosition struct {
+	X float64 `yaml:"x"`
+	Y float64 `yaml:"y"`
+}
+
+type yamlNode[T comparable] struct {
+	Id       string         `yaml:"id"`
+	Type     string         `yaml:"type,omitempty"`
+	Data     T              `yaml:"data,omitempty"`
+	Position *yamlPosition  `yaml:"position,omitempty,flow"`
+	Width    float64        `yaml:"width,omitempty"`
+	Height   float64        `yaml:"height,omitempty"`
+	ParentId string         `yaml:"parentId,omitempty"`
+	Extent   any            `yaml:"extent,omitempty"`
+	ZIndex   *int           `yaml:"zIndex,omitempty"`
+	Hidden   bool           `yaml:"hidden,omitempty"`
+	Style    any            `yaml:"style,omitempty"`
+	Extra    map[string]any `yaml:"extra,omitempty"`
+}
+
+type yamlEdge struct {
+	Id           string         `yaml:"id,omitempty"`
+	Source       string         `yaml:"source"`
+	Target       string         `yaml:"target"`
+	SourceHandle *string        `yaml:"sourceHandle,omitempty"`
+	TargetHandle *string        `yaml:"targetHandle,omitempty"`
+	Label        string         `yaml:"label,omitempty"`
+	Type         string         `yaml:"type,omitempty"`
+	Animated     *bool          `yaml:"animated,omitempty"`
+	Hidden       bool           `yaml:"hidden,omitempty"`
+	ZIndex       *int           `yaml:"zIndex,omitempty"`
+	Style        any            `yaml:"style,omitempty"`
+	Data         any            `yaml:"data,omitempty"`
+	MarkerStart  any            `yaml:"markerStart,omitempty"`
+	MarkerEnd    any            `yaml:"markerEnd,omitempty"`
+	Extra        map[string]any `yaml:"extra,omitempty"`
+}
+
+// EncodeYAML writes the flowchart as YAML meant to be kept in version control.
+// Nodes are sorted by id, edges by source and target, and map keys by name, so
+// the same flowchart always gives the same file. Edge ids are left out when
+// they are the ones React Flow would give.
+func EncodeYAML[T comparable](flowChart *FlowChartDto[T]) ([]byte, error) {
+	document := yamlFlowChart[T]{
+		Title: flowChart.Title,
+		Key:   flowChart.Key,
+		Nodes: make([]yamlNode[T], 0, len(flowChart.Nodes)),
+		Edges: make([]yamlEdge, 0, len(flowChart.Edges)),
+	}
+
+	if viewport := flowChart.Viewport; viewport != nil && ViewportToDomain(viewport) != domain.DefaultViewport {
+		document.Viewport = &yamlViewport{X: viewport.X, Y: viewport.Y, Zoom: viewport.Zoom}
+	}
+
+	for _, n := range flowChart.Nodes {
+		node := yamlNode[T]{
+			Id:       n.Id,
+			Type:     n.Type,
+			Data:     n.Data,
+			Position: &yamlPosition{X: n.Position.X, Y: n.Position.Y},
+			Width:    n.Width,
+			Height:   n.Height,
+			ParentId: n.ParentId,
+			ZIndex:   n.ZIndex,
+			Hidden:   n.Hidden,
+		}
+
+		if node.ParentId == "" {
+			node.ParentId = n.ParentNode
+		}
+
+		var err error
+		if node.Extent, err = yamlValue(n.Extent); err != nil {
+			return nil, err
+		}
+		if node.Style, err = yamlValue(n.Style); err != nil {
+			return nil, err
+		}
+		if node.Extra, err = yamlExtra(n.Extra); err != nil {
+			return nil, err
+		}
+
+		document.Nodes = append(document.Nodes, node)
+	}
+
+	for _, e := range flowChart.Edges {
+		edge := yamlEdge{
+			Id:           e.Id,
+			Source:       e.Source,
+			Target:       e.Target,
+			SourceHandle: e.SourceHandle,
+			TargetHandle: e.TargetHandle,
+			Label:        e.Label,
+			Type:         e.Type,
+			Animated:     e.Animated,
+			Hidden:       e.Hidden,
+			ZIndex:       e.ZIndex,
+		}
+
+		if edge.Id == domain.EdgeID(e.Source, e.Target) {
+			edge.Id = ""
+		}
+
+		var err error
+		if edge.Style, err = yamlValue(e.Style); err != nil {
+			return nil, err
+		}
+		if edge.Data, err = yamlValue(e.Data); err != nil {
+			return nil, err
+		}
+		if edge.MarkerStart, err = yamlValue(e.MarkerStart); err != nil {
+			return nil, err
+		}
+		if edge.MarkerEnd, err = yamlValue(e.MarkerEnd); err != nil {
+			return nil, err
+		}
+		if edge.Extra, err = yamlExtra(e.Extra); err != nil {
+			return nil, err
+		}
+
+		document.Edges = append(document.Edges, edge)
+	}
+
+	sort.SliceStable(document.Nodes, func(i, j int) bool {
+		return document.Nodes[i].Id < document.Nodes[j].Id
+	})
+
+	sort.SliceStable(document.Edges, func(i, j int) bool {
+		a, b := document.Edges[i], document.Edges[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Id < b.Id
+	})
+
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DecodeYAML reads a flowchart written by EncodeYAML or by hand, rejecting
+// unknown fields. Edges without an id get the one React Flow would give them.
+// It also returns the ids of the nodes given a position.
+func DecodeYAML[T comparable](source []byte) (*FlowChartDto[T], map[string]bool, error) {
+	document := yamlFlowChart[T]{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(source))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil, err
+	}
+
+	flowChart := &FlowChartDto[T]{
+		Title: document.Title,
+		Key:   document.Key,
+		Nodes: make([]*NodeDto[T], 0, len(document.Nodes)),
+		Edges: make([]*EdgeDto, 0, len(document.Edges)),
+	}
+
+	if viewport := document.Viewport; viewport != nil {
+		flowChart.Viewport = &ViewportDto{X: viewport.X, Y: viewport.Y, Zoom: viewport.Zoom}
+	}
+
+	positioned := make(map[string]bool, len(document.Nodes))
+	for _, n := range document.Nodes {
+		node := &NodeDto[T]{
+			Id:         n.Id,
+			Type:       n.Type,
+			Data:       n.Data,
+			Width:      n.Width,
+			Height:     n.Height,
+			ParentNode: n.ParentId,
+			ParentId:   n.ParentId,
+			ZIndex:     n.ZIndex,
+			Hidden:     n.Hidden,
+		}
+
+		if n.Position != nil {
+			node.Position = PositionDto{X: n.Position.X, Y: n.Position.Y}
+			positioned[n.Id] = true
+		}
+
+		var err error
+		if node.Extent, err = jsonValue(n.Extent); err != nil {
+			return nil, nil, err
+		}
+		if node.Style, err = jsonValue(n.Style); err != nil {
+			return nil, nil, err
+		}
+		if node.Extra, err = jsonExtra(n.Extra); err != nil {
+			return nil, nil, err
+		}
+
+		flowChart.Nodes = append(flowChart.Nodes, node)
+	}
+
+	for _, e := range document.Edges {
+		edge := &EdgeDto{
+			Id:           e.Id,
+			Source:       e.Source,
+			Target:       e.Target,
+			SourceHandle: e.SourceHandle,
+			TargetHandle: e.TargetHandle,
+			Label:        e.Label,
+			Type:         e.Type,
+			Animated:     e.Animated,
+			Hidden:       e.Hidden,
+			ZIndex:       e.ZIndex,
+		}
+
+		if edge.Id == "" {
+			edge.Id = domain.EdgeID(e.Source, e.Target)
+		}
+
+		var err error
+		if edge.Style, err = jsonValue(e.Style); err != nil {
+			return nil, nil, err
+		}
+		if edge.Data, err = jsonValue(e.Data); err != nil {
+			return nil, nil, err
+		}
+		if edge.MarkerStart, err = jsonValue(e.MarkerStart); err != nil {
+			return nil, nil, err
+		}
+		if edge.MarkerEnd, err = jsonValue(e.MarkerEnd); err != nil {
+			return nil, nil, err
+		}
+		if edge.Extra, err = jsonExtra(e.Extra); err != nil {
+			return nil, nil, err
+		}
+
+		flowChart.Edges = append(flowChart.Edges, edge)
+	}
+
+	setAbsolutePositions(flowChart.Nodes)
+
+	return flowChart, positioned, nil
+}
+
+// setAbsolutePositions adds the positions of the groups a node is drawn inside
+// to its own, which is relative to its group.
+func setAbsolutePositions[T comparable](nodes []*NodeDto[T]) {
+	byID := make(map[string]*NodeDto[T], len(nodes))
+	for _, node := range nodes {
+		byID[node.Id] = node
+	}
+
+	for _, node := range nodes {
+		absolute := node.Position
+		seen := map[string]bool{node.Id: true}
+
+		for parent, ok := byID[node.ParentId]; ok && !seen[parent.Id]; parent, ok = byID[parent.ParentId] {
+			seen[parent.Id] = true
+			absolute.X += parent.Position.X
+			absolute.Y += parent.Position.Y
+		}
+
+		node.PositionAbsolute = absolute
+	}
+}
+
+// yamlValue turns raw JSON into the value written in its place in YAML.
+func yamlValue(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var value any
+	err := json.Unmarshal(raw, &value)
+	return value, err
+}
+
+// jsonValue turns a value read from YAML back into raw JSON.
+func jsonValue(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(value)
+}
+
+// yamlExtra turns the fields kept in Extra into the values written in YAML.
+func yamlExtra(extra domain.Extra) (map[string]any, error) {
+	if len(extra) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]any, len(extra))
+	for name, raw := range extra {
+		value, err := yamlValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+// jsonExtra turns the extra fields read from YAML back into raw JSON.
+func jsonExtra(values map[string]any) (domain.Extra, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	extra := make(domain.Extra, len(values))
+	for name, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		extra[name] = raw
+	}
+
+	return extra, nil
+}